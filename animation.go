@@ -1,14 +1,15 @@
 package engi
 
 import (
-	"log"
-
 	"github.com/paked/engi/ecs"
 )
 
 type AnimationAction struct {
 	Name   string
 	Frames []int
+	// Durations optionally holds the number of seconds each frame is shown; frames without a duration, or
+	// with a duration of 0, are shown for the Rate of the AnimationComponent
+	Durations []float32
 }
 
 // Component that controls animation in rendering entities
@@ -19,6 +20,11 @@ type AnimationComponent struct {
 	Drawables        []Drawable       // Renderables
 	Animations       map[string][]int // All possible animations
 	CurrentAnimation []int            // The current animation
+
+	durations        map[string][]float32 // The durations of the frames of the animations that have them
+	currentName      string
+	currentDurations []float32
+	warned           bool // Whether the missing animation has been logged, which is done once per animation
 }
 
 func NewAnimationComponent(drawables []Drawable, rate float32) *AnimationComponent {
@@ -29,22 +35,44 @@ func NewAnimationComponent(drawables []Drawable, rate float32) *AnimationCompone
 	}
 }
 
+// SelectAnimationByName plays the animation with the given name; it starts from its first frame, unless it
+// was playing already
 func (ac *AnimationComponent) SelectAnimationByName(name string) {
+	if name != ac.currentName {
+		ac.index, ac.change = 0, 0
+		ac.warned = false
+	}
+	ac.currentName = name
 	ac.CurrentAnimation = ac.Animations[name]
+	ac.currentDurations = ac.durations[name]
 }
 
 func (ac *AnimationComponent) SelectAnimationByAction(action *AnimationAction) {
-	ac.CurrentAnimation = ac.Animations[action.Name]
+	ac.SelectAnimationByName(action.Name)
 }
 
 func (ac *AnimationComponent) AddAnimationAction(action *AnimationAction) {
 	ac.Animations[action.Name] = action.Frames
+	if len(action.Durations) > 0 {
+		if ac.durations == nil {
+			ac.durations = make(map[string][]float32)
+		}
+		ac.durations[action.Name] = action.Durations
+	}
 }
 
 func (ac *AnimationComponent) AddAnimationActions(actions []*AnimationAction) {
 	for _, action := range actions {
-		ac.Animations[action.Name] = action.Frames
+		ac.AddAnimationAction(action)
+	}
+}
+
+// frameDuration returns the number of seconds the current frame is shown
+func (ac *AnimationComponent) frameDuration() float32 {
+	if ac.index < len(ac.currentDurations) && ac.currentDurations[ac.index] > 0 {
+		return ac.currentDurations[ac.index]
 	}
+	return ac.Rate
 }
 
 func (ac *AnimationComponent) Cell() Drawable {
@@ -55,7 +83,10 @@ func (ac *AnimationComponent) Cell() Drawable {
 
 func (ac *AnimationComponent) NextFrame() {
 	if len(ac.CurrentAnimation) == 0 {
-		log.Println("No data for this animation")
+		if !ac.warned {
+			logWarn(LogEngine, "no data for this animation", "animation", ac.currentName)
+			ac.warned = true
+		}
 		return
 	}
 
@@ -72,6 +103,9 @@ func (*AnimationComponent) Type() string {
 
 type AnimationSystem struct {
 	*ecs.System
+
+	// IgnoreTimeScale indicates whether animations should keep their speed when Time is paused or slowed down
+	IgnoreTimeScale bool
 }
 
 func (a *AnimationSystem) New(*ecs.World) {
@@ -82,6 +116,10 @@ func (AnimationSystem) Type() string {
 	return "AnimationSystem"
 }
 
+func (a *AnimationSystem) Unscaled() bool {
+	return a.IgnoreTimeScale
+}
+
 func (a *AnimationSystem) Update(e *ecs.Entity, dt float32) {
 	var (
 		ac *AnimationComponent
@@ -97,7 +135,7 @@ func (a *AnimationSystem) Update(e *ecs.Entity, dt float32) {
 	}
 
 	ac.change += dt
-	if ac.change >= ac.Rate {
+	if ac.change >= ac.frameDuration() {
 		ac.NextFrame()
 		r.SetDrawable(ac.Cell())
 	}
@@ -1,11 +1,14 @@
 package engi
 
 import (
+	"errors"
+	"fmt"
 	"image/color"
-	"io/ioutil"
-	"log"
-	"os"
+	"io/fs"
 	"path"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/golang/freetype/truetype"
 	"github.com/luxengine/math"
@@ -16,113 +19,564 @@ type Resource struct {
 	kind string
 	name string
 	url  string
+	fsys fs.FS
+
+	settings AssetSettings
+	// stage is the number of bundles the resource's bundle depends on, in a row; see Loading.order
+	stage int
+	// bundle is the manifest the resource was added by, if any
+	bundle *bundle
+}
+
+// Kind returns the extension of the resource, without the leading dot; it selects the AssetLoader
+func (r Resource) Kind() string {
+	return r.kind
+}
+
+// Name returns the name under which the asset can be retrieved from the Loader
+func (r Resource) Name() string {
+	return r.name
+}
+
+// URL returns the location the resource is read from
+func (r Resource) URL() string {
+	return r.url
+}
+
+// Settings returns the settings of the resource, which are set in manifests
+func (r Resource) Settings() AssetSettings {
+	return r.settings
+}
+
+// AssetSettings are optional settings of an asset, which can be set in manifests
+type AssetSettings struct {
+	// Filter is the texture filtering of images, either "linear" or "nearest"; by default, images are filtered
+	// linearly when scaled down, and nearest when scaled up
+	Filter string `json:"filter,omitempty"`
+	// Size is the default size of fonts
+	Size float64 `json:"size,omitempty"`
+	// Scale is the resolution SVGs are rasterised at when they are loaded, relative to their own size
+	Scale float64 `json:"scale,omitempty"`
+	// Loop makes sounds repeat by default
+	Loop bool `json:"loop,omitempty"`
 }
 
 type Loader struct {
 	resources []Resource
-	images    map[string]*Texture
-	jsons     map[string]string
-	levels    map[string]*Level
-	sounds    map[string]string
-	fonts     map[string]*truetype.Font
+	assets    map[string]*loadedAsset
+	mounts    []fs.FS
+	// aliases maps the short names of the assets, without their directories, to their full names
+	aliases map[string][]string
+	// bundles are the manifests which have been added, by name
+	bundles map[string]*bundle
+	atlases map[string]*Atlas
+
+	// Strict makes Scenes of which any resource could not be loaded not be set up at all; otherwise they are
+	// set up anyway. Either way, a SceneLoadFailedMessage with the *LoadError is dispatched on the Bus, so the
+	// game can show the error, or switch to another Scene.
+	Strict bool
+
+	loading *Loading
+
+	// hot reloading, see SetHotReload
+	reloadInterval time.Duration
+	lastReloadPoll time.Time
+	reloading      *Loading
+}
+
+// ErrMissingAsset is returned by the getters of the Loader (wrapped with the name of the asset), whenever the
+// asset hasn't been loaded
+var ErrMissingAsset = errors.New("missing asset")
+
+func missingAsset(kind, name string) error {
+	return fmt.Errorf("%w: %s %s", ErrMissingAsset, kind, name)
+}
+
+// ErrAssetCollision is returned by the getters of the Loader (wrapped with the names of the assets), whenever
+// an asset is retrieved by its short name, and multiple assets in different directories have that name
+var ErrAssetCollision = errors.New("ambiguous asset name")
+
+// loadedAsset is an asset along with the number of references to it
+type loadedAsset struct {
+	Resource
+	data   interface{}
+	loader AssetLoader
+	refs   int
+	// modTime is the modification time of the file when it was loaded, used for hot reloading
+	modTime time.Time
 }
 
 func NewLoader() *Loader {
 	return &Loader{
-		resources: make([]Resource, 1),
-		images:    make(map[string]*Texture),
-		jsons:     make(map[string]string),
-		levels:    make(map[string]*Level),
-		sounds:    make(map[string]string),
-		fonts:     make(map[string]*truetype.Font),
+		resources: make([]Resource, 0),
+		assets:    make(map[string]*loadedAsset),
+		aliases:   make(map[string][]string),
+		bundles:   make(map[string]*bundle),
+		atlases:   make(map[string]*Atlas),
+	}
+}
+
+// lookup finds the asset by its full name, or by its short name as long as no other asset has that name
+func (l *Loader) lookup(kind, name string) (*loadedAsset, error) {
+	if a, ok := l.assets[assetName(name)]; ok {
+		return a, nil
+	}
+
+	switch names := l.aliases[name]; len(names) {
+	case 0:
+		return nil, missingAsset(kind, name)
+	case 1:
+		return l.assets[names[0]], nil
+	default:
+		return nil, fmt.Errorf("%w: %s %s could be any of %s", ErrAssetCollision, kind, name, strings.Join(names, ", "))
+	}
+}
+
+// asset returns the asset with the given name, or nil
+func (l *Loader) asset(name string) interface{} {
+	if a, _ := l.lookup("asset", name); a != nil {
+		return a.data
+	}
+	return nil
+}
+
+// getAsset returns the asset with the given name if it is a T
+func getAsset[T any](l *Loader, kind, name string) (T, error) {
+	var zero T
+	a, err := l.lookup(kind, name)
+	if err != nil {
+		return zero, err
 	}
+	asset, ok := a.data.(T)
+	if !ok {
+		return zero, missingAsset(kind, name)
+	}
+	return asset, nil
 }
 
+// NewResource creates the Resource for the file at the URL. Its name is the cleaned up URL, i.e.
+// "levels/forest/tiles.png", but it can be retrieved by its short name "tiles.png" as well, as long as no
+// other asset has that short name.
 func NewResource(url string) Resource {
-	kind := path.Ext(url)
-	//name := strings.TrimSuffix(path.Base(url), kind)
-	name := path.Base(url)
-	return Resource{name: name, url: url, kind: kind[1:]}
+	kind := strings.TrimPrefix(path.Ext(url), ".")
+	return Resource{name: assetName(url), url: url, kind: kind}
+}
+
+// assetName turns the URL into the full name of its asset
+func assetName(url string) string {
+	return path.Clean(url)
+}
+
+// resolvePath resolves the reference relative to the directory of the file which contains it, like the
+// tileset images in a TMX file
+func resolvePath(file, ref string) string {
+	if path.IsAbs(ref) {
+		return assetName(ref)
+	}
+	return assetName(path.Join(path.Dir(file), ref))
+}
+
+// AssetLoader turns resources of a single kind into assets
+type AssetLoader struct {
+	// Decode reads and decodes the resource; it is called in the background, so it must not use the GL context
+	Decode func(r Resource) (interface{}, error)
+	// Finish optionally turns the decoded data into the asset; it is called on the main thread, i.e. to
+	// upload textures to the GPU
+	Finish func(r Resource, data interface{}) (interface{}, error)
+	// Reload optionally updates the asset in place with the newly decoded data, when its file has changed
+	// and hot reloading is enabled, so existing references see the change; it is called on the main thread.
+	// Without it, the asset is finished and replaced, and only Get and the like return the new one.
+	Reload func(asset interface{}, r Resource, data interface{}) error
+	// Late resources are finished after all other resources which are loaded along with them, so they can
+	// use those, like levels using their tileset images
+	Late bool
+	// Unload optionally releases the asset once it is unloaded, i.e. deletes the GL texture; it is called on
+	// the main thread
+	Unload func(asset interface{})
+	// Size optionally estimates the memory used by the asset in bytes, for the MemoryReport
+	Size func(asset interface{}) int
+}
+
+// loaders holds the AssetLoader per kind of resource
+var loaders = map[string]AssetLoader{
+	"png":  {Decode: decodeImage, Finish: finishImage, Reload: reloadImage, Unload: unloadImage, Size: imageSize},
+	"jpg":  {Decode: decodeImage, Finish: finishImage, Reload: reloadImage, Unload: unloadImage, Size: imageSize},
+	"jpeg": {Decode: decodeImage, Finish: finishImage, Reload: reloadImage, Unload: unloadImage, Size: imageSize},
+	"bmp":  {Decode: decodeImage, Finish: finishImage, Reload: reloadImage, Unload: unloadImage, Size: imageSize},
+	"webp": {Decode: decodeImage, Finish: finishImage, Reload: reloadImage, Unload: unloadImage, Size: imageSize},
+	"svg":  {Decode: decodeSVG, Finish: finishSVG, Reload: reloadSVG, Unload: unloadSVG, Size: svgSize},
+	"gif": {
+		Decode: decodeGif,
+		Finish: finishFrameSheet,
+		Reload: reloadFrameSheet,
+		Unload: unloadFrameSheet,
+		Size:   frameSheetSize,
+	},
+	"json": {
+		Decode: func(r Resource) (interface{}, error) { return loadJSON(r) },
+		Size:   func(asset interface{}) int { return len(asset.(string)) },
+	},
+	"tmx": {
+		Decode: func(r Resource) (interface{}, error) { return decodeTmx(r) },
+		Finish: finishLevel,
+		Reload: reloadLevel,
+		Late:   true,
+		Unload: func(asset interface{}) { Files.Release(asset.(*Level).images...) },
+	},
+	"wav": {Decode: func(r Resource) (interface{}, error) { return soundFile(r.url), nil }},
+	"ttf": {Decode: func(r Resource) (interface{}, error) { return loadFont(r) }},
+	"spritesheet": {
+		Decode: decodeFrameSheet,
+		Finish: finishFrameSheet,
+		Reload: reloadFrameSheet,
+		Unload: unloadFrameSheet,
+		Size:   frameSheetSize,
+	},
+}
+
+func decodeImage(r Resource) (interface{}, error) {
+	return loadImage(r)
 }
 
-func (l *Loader) AddFromDir(url string, recurse bool) {
-	files, err := ioutil.ReadDir(url)
+func finishImage(r Resource, data interface{}) (interface{}, error) {
+	tex := NewTexture(data.(Image))
+	tex.source = func() (Image, error) {
+		return loadImage(r)
+	}
+	if headless {
+		return tex, nil
+	}
+
+	switch r.settings.Filter {
+	case "linear":
+		tex.SetFilter(Gl.LINEAR, Gl.LINEAR)
+	case "nearest":
+		tex.SetFilter(Gl.NEAREST, Gl.NEAREST)
+	}
+	return tex, nil
+}
+
+// finishLevel creates the Level, which keeps its images loaded as long as it is loaded itself
+func finishLevel(r Resource, data interface{}) (interface{}, error) {
+	lvl, err := createLevel(data.(*TMXLevel))
+	if err != nil {
+		return nil, err
+	}
+	return lvl, Files.Retain(lvl.images...)
+}
+
+func reloadImage(asset interface{}, r Resource, data interface{}) error {
+	asset.(*Texture).upload(data.(Image))
+	return nil
+}
+
+// reloadLevel replaces the contents of the Level, and keeps the images of the new contents loaded instead
+func reloadLevel(asset interface{}, r Resource, data interface{}) error {
+	created, err := finishLevel(r, data)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	lvl := asset.(*Level)
+	previous := lvl.images
+	*lvl = *created.(*Level)
+	Files.Release(previous...)
+	return nil
+}
+
+func unloadImage(asset interface{}) {
+	asset.(*Texture).Delete()
+}
+
+func imageSize(asset interface{}) int {
+	tex := asset.(*Texture)
+	size := int(tex.width) * int(tex.height) * 4
+	if tex.image != nil {
+		size *= 2
+	}
+	return size
+}
+
+// soundFile is the asset of a sound, which is streamed from its file whenever it is played
+type soundFile string
+
+// RegisterLoader makes the Loader use the AssetLoader for resources with the given extension (without the
+// leading dot), replacing the one which was registered before, if any. It has to be called before the
+// resources are loaded, i.e. from an init function.
+func RegisterLoader(ext string, loader AssetLoader) {
+	loaders[ext] = loader
+}
+
+// RegisterDecoder is a shorthand for RegisterLoader, for assets which don't need the main thread:
+//
+//	engi.RegisterDecoder("dialogue", func(r engi.Resource) (*Dialogue, error) {
+//		data, err := r.ReadAll()
+//		if err != nil {
+//			return nil, err
+//		}
+//		return parseDialogue(data)
+//	})
+func RegisterDecoder[T any](ext string, decode func(r Resource) (T, error)) {
+	RegisterLoader(ext, AssetLoader{Decode: func(r Resource) (interface{}, error) {
+		return decode(r)
+	}})
+}
+
+// Get returns the asset with the given name, or an error wrapping ErrMissingAsset if it hasn't been loaded
+// or isn't a T. Go doesn't allow methods with type parameters, hence it isn't Loader.Get:
+//
+//	dialogue, err := engi.Get[*Dialogue](engi.Files, "intro.dialogue")
+func Get[T any](l *Loader, name string) (T, error) {
+	var zero T
+	return getAsset[T](l, fmt.Sprintf("%T", zero), name)
+}
+
+// AddFromDir adds all files in the directory, and optionally those in its subdirectories
+func (l *Loader) AddFromDir(url string, recurse bool) error {
+	files, err := fs.ReadDir(l.FS(), url)
+	if err != nil {
+		return err
 	}
 	for _, f := range files {
 		furl := url + "/" + f.Name()
 		if !f.IsDir() {
-			Files.Add(furl)
+			l.Add(furl)
 		} else if recurse {
-			Files.AddFromDir(furl, recurse)
+			if err := l.AddFromDir(furl, recurse); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
 }
 
 func (l *Loader) Add(urls ...string) {
 	for _, u := range urls {
 		r := NewResource(u)
 		l.resources = append(l.resources, r)
-		log.Println(r)
+		logDebug(LogAssets, "added resource", "name", r.name, "url", r.url)
+	}
+}
+
+// AddAs adds the resources like Add, but loads them as the given kind regardless of their extension, i.e.
+// "spritesheet" for the JSON files of TexturePacker and Aseprite
+func (l *Loader) AddAs(kind string, urls ...string) {
+	for _, u := range urls {
+		r := NewResource(u)
+		r.kind = kind
+		l.resources = append(l.resources, r)
+		logDebug(LogAssets, "added resource", "name", r.name, "url", r.url, "kind", kind)
 	}
 }
 
 func (l *Loader) Image(name string) *Texture {
-	return l.images[name]
+	tex, _ := l.GetImage(name)
+	return tex
 }
 
 func (l *Loader) Json(name string) string {
-	return l.jsons[name]
+	json, _ := l.GetJson(name)
+	return json
+}
+
+// GetImage is like Image, but returns an error wrapping ErrMissingAsset if the image hasn't been loaded
+func (l *Loader) GetImage(name string) (*Texture, error) {
+	a, err := l.lookup("image", name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch asset := a.data.(type) {
+	case *Texture:
+		return asset, nil
+	case *FrameSheet:
+		// GIFs are loaded as FrameSheets, of which the texture holds all frames
+		return asset.still(), nil
+	case *SVG:
+		return asset.texture, nil
+	}
+	return nil, missingAsset("image", name)
+}
+
+// GetJson is like Json, but returns an error wrapping ErrMissingAsset if the JSON file hasn't been loaded
+func (l *Loader) GetJson(name string) (string, error) {
+	return getAsset[string](l, "json", name)
+}
+
+// GetLevel is like Level, but returns an error wrapping ErrMissingAsset if the level hasn't been loaded
+func (l *Loader) GetLevel(name string) (*Level, error) {
+	return getAsset[*Level](l, "level", name)
+}
+
+// Settings returns the settings of the asset, which are set in manifests
+func (l *Loader) Settings(name string) AssetSettings {
+	if a, _ := l.lookup("asset", name); a != nil {
+		return a.settings
+	}
+	return AssetSettings{}
+}
+
+// GetSound is like Sound, but returns an error wrapping ErrMissingAsset if the sound hasn't been loaded, or
+// the error that occurred while opening it
+func (l *Loader) GetSound(name string) (ReadSeekCloser, error) {
+	url, err := getAsset[soundFile](l, "sound", name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := l.Open(string(url))
+	if err != nil {
+		return nil, err
+	}
+	return readSeekCloser(f)
+}
+
+// GetFont returns the font, or an error wrapping ErrMissingAsset if the font hasn't been loaded
+func (l *Loader) GetFont(name string) (*truetype.Font, error) {
+	return getAsset[*truetype.Font](l, "font", name)
 }
 
 func (l *Loader) Level(name string) *Level {
-	return l.levels[name]
+	lvl, _ := l.GetLevel(name)
+	return lvl
 }
 
 func (l *Loader) Sound(name string) ReadSeekCloser {
-	f, err := os.Open(l.sounds[name])
+	f, err := l.GetSound(name)
 	if err != nil {
 		return nil
 	}
 	return f
 }
 
-func (l *Loader) Load(onFinish func()) {
-	for _, r := range l.resources {
-		switch r.kind {
-		case "png":
-			data, err := loadImage(r)
-			if err == nil {
-				l.images[r.name] = NewTexture(data)
-			}
-		case "jpg":
-			data, err := loadImage(r)
-			if err == nil {
-				l.images[r.name] = NewTexture(data)
-			}
-		case "json":
-			data, err := loadJSON(r)
-			if err == nil {
-				l.jsons[r.name] = data
-			}
-		case "tmx":
-			data, err := createLevelFromTmx(r)
-			if err == nil {
-				l.levels[r.name] = data
-			}
-		case "wav":
-			l.sounds[r.name] = r.url
-		case "ttf":
-			f, err := loadFont(r)
-			if err == nil {
-				l.fonts[r.name] = f
-			} else {
-				log.Printf("Could not load font %s\n", r.name)
-			}
+// store makes the decoded resource available with a single reference; it is called on the main thread,
+// because textures have to be uploaded to the GPU. An asset that is replaced keeps its references.
+func (l *Loader) store(r Resource, loader AssetLoader, data interface{}, modTime time.Time) error {
+	if loader.Finish != nil {
+		var err error
+		if data, err = loader.Finish(r, data); err != nil {
+			return err
+		}
+	}
+
+	refs := 1
+	if old, ok := l.assets[r.name]; ok {
+		refs += old.refs
+		l.remove(old)
+	}
+	l.assets[r.name] = &loadedAsset{Resource: r, data: data, loader: loader, refs: refs, modTime: modTime}
+
+	short := path.Base(r.name)
+	l.aliases[short] = append(l.aliases[short], r.name)
+	if len(l.aliases[short]) > 1 {
+		logWarn(LogAssets, "short asset name is ambiguous, use the full name instead", "name", short, "assets", l.aliases[short])
+	}
+	return nil
+}
+
+// Retain adds a reference to each of the assets, so they stay loaded until they are released as often
+func (l *Loader) Retain(names ...string) error {
+	for _, name := range names {
+		a, err := l.lookup("asset", name)
+		if err != nil {
+			return err
+		}
+		a.refs++
+	}
+	return nil
+}
+
+// Release removes a reference from each of the assets, and unloads those which aren't referenced anymore.
+// Assets which aren't loaded are ignored.
+func (l *Loader) Release(names ...string) {
+	for _, name := range names {
+		a, err := l.lookup("asset", name)
+		if err != nil {
+			continue
+		}
+		if a.refs--; a.refs <= 0 {
+			l.remove(a)
 		}
 	}
+}
+
+// Unload unloads the asset right away, regardless of its references, and returns an error wrapping
+// ErrMissingAsset if it isn't loaded. Textures can't be drawn anymore after they are unloaded.
+func (l *Loader) Unload(name string) error {
+	a, err := l.lookup("asset", name)
+	if err != nil {
+		return err
+	}
+	l.remove(a)
+	return nil
+}
+
+// remove unloads the asset, and forgets about it
+func (l *Loader) remove(a *loadedAsset) {
+	delete(l.assets, a.name)
+
+	short := path.Base(a.name)
+	names := l.aliases[short][:0]
+	for _, name := range l.aliases[short] {
+		if name != a.name {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		delete(l.aliases, short)
+	} else {
+		l.aliases[short] = names
+	}
+
+	l.unload(a)
+}
+
+func (l *Loader) unload(a *loadedAsset) {
+	if a.loader.Unload != nil {
+		a.loader.Unload(a.data)
+	}
+	logDebug(LogAssets, "unloaded asset", "name", a.name, "url", a.url)
+}
+
+// AssetInfo describes a loaded asset
+type AssetInfo struct {
+	Name string
+	URL  string
+	Kind string
+	// Refs is the number of references to the asset, i.e. the number of Scenes using it
+	Refs int
+	// Size is an estimate of the memory used by the asset in bytes, or 0 if it is unknown; for textures,
+	// this is the memory used on the GPU
+	Size int
+}
+
+// MemoryReport lists all loaded assets, largest first
+func (l *Loader) MemoryReport() []AssetInfo {
+	report := make([]AssetInfo, 0, len(l.assets))
+	for name, a := range l.assets {
+		info := AssetInfo{Name: name, URL: a.url, Kind: a.kind, Refs: a.refs}
+		if a.loader.Size != nil {
+			info.Size = a.loader.Size(a.data)
+		}
+		report = append(report, info)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Size != report[j].Size {
+			return report[i].Size > report[j].Size
+		}
+		return report[i].Name < report[j].Name
+	})
+	return report
+}
+
+// Load loads all resources that have been added since the previous load, and blocks until they are done.
+// It returns a *LoadError listing every resource that could not be loaded; onFinish is called either way.
+func (l *Loader) Load(onFinish func()) error {
+	ld := l.LoadAsync()
+	ld.Wait()
 	onFinish()
+	return ld.Err()
 }
 
 type Image interface {
@@ -155,6 +609,13 @@ type Region struct {
 	u, v          float32
 	u2, v2        float32
 	width, height float32
+
+	// rotated is set when the region is stored in its texture rotated 90 degrees clockwise
+	rotated bool
+	// trimmed is set when the transparent edges of the region have been cut off; the rest is drawn at trimX,
+	// trimY within the width and height of the original
+	trimmed                    bool
+	trimX, trimY, trimW, trimH float32
 }
 
 func NewRegion(texture *Texture, x, y, w, h float32) *Region {
@@ -169,7 +630,7 @@ func NewRegion(texture *Texture, x, y, w, h float32) *Region {
 	width := math.Abs(w)
 	height := math.Abs(h)
 
-	return &Region{texture, u, v, u2, v2, width, height}
+	return &Region{texture: texture, u: u, v: v, u2: u2, v2: v2, width: width, height: height}
 }
 
 func (r *Region) Width() float32 {
@@ -184,36 +645,74 @@ func (r *Region) Texture() *webgl.Texture {
 	return r.texture.id
 }
 
+// View returns the part of the GL texture the Region is drawn from; it follows the Texture into an atlas
 func (r *Region) View() (float32, float32, float32, float32) {
-	return r.u, r.v, r.u2, r.v2
+	u, v, u2, v2 := r.texture.View()
+	w, h := u2-u, v2-v
+	return u + r.u*w, v + r.v*h, u + r.u2*w, v + r.v2*h
 }
 
 type Texture struct {
 	id     *webgl.Texture
 	width  float32
 	height float32
+
+	// image is the CPU copy of textures which are edited, and of all textures when headless; the others read
+	// their pixels from their source again when they are needed, i.e. to pack them into an atlas
+	image  Image
+	keep   bool
+	source func() (Image, error)
+	// atlas is set when the texture is drawn from a page of an atlas, at the view
+	atlas          *Atlas
+	atlasX, atlasY int
+	u, v, u2, v2   float32
+	// owner is the texture a texture which only draws a part of it draws from, which owns the GL texture
+	owner *Texture
 }
 
 func NewTexture(img Image) *Texture {
-	var id *webgl.Texture
-	if !headless {
-		id = Gl.CreateTexture()
+	t := &Texture{u2: 1, v2: 1}
+	t.upload(img)
+	return t
+}
 
-		Gl.BindTexture(Gl.TEXTURE_2D, id)
+// upload replaces the image of the texture, and creates the GL texture if needed
+func (t *Texture) upload(img Image) {
+	// Images that don't fit in the atlas anymore get a texture of their own
+	if t.atlas != nil && (img.Width() != int(t.width) || img.Height() != int(t.height)) {
+		t.unpack()
+	}
 
-		Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_WRAP_S, Gl.CLAMP_TO_EDGE)
-		Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_WRAP_T, Gl.CLAMP_TO_EDGE)
-		Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_MIN_FILTER, Gl.LINEAR)
-		Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_MAG_FILTER, Gl.NEAREST)
+	if !headless && t.atlas != nil {
+		Gl.BindTexture(Gl.TEXTURE_2D, t.id)
+		Gl.TexSubImage2D(Gl.TEXTURE_2D, 0, t.atlasX, t.atlasY, img.Width(), img.Height(), Gl.RGBA, Gl.UNSIGNED_BYTE, glPixels(img))
+	} else if !headless {
+		if t.id == nil {
+			t.id = Gl.CreateTexture()
+
+			Gl.BindTexture(Gl.TEXTURE_2D, t.id)
+
+			Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_WRAP_S, Gl.CLAMP_TO_EDGE)
+			Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_WRAP_T, Gl.CLAMP_TO_EDGE)
+			Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_MIN_FILTER, Gl.LINEAR)
+			Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_MAG_FILTER, Gl.NEAREST)
+		} else {
+			Gl.BindTexture(Gl.TEXTURE_2D, t.id)
+		}
 
 		if img.Data() == nil {
 			panic("Texture image data is nil.")
 		}
 
-		Gl.TexImage2D(Gl.TEXTURE_2D, 0, Gl.RGBA, Gl.RGBA, Gl.UNSIGNED_BYTE, img.Data())
+		Gl.TexImage2D(Gl.TEXTURE_2D, 0, Gl.RGBA, Gl.RGBA, Gl.UNSIGNED_BYTE, glPixels(img))
 	}
 
-	return &Texture{id, float32(img.Width()), float32(img.Height())}
+	t.image = nil
+	if t.keep || headless {
+		t.image = img
+	}
+	t.width = float32(img.Width())
+	t.height = float32(img.Height())
 }
 
 // Width returns the width of the texture.
@@ -230,8 +729,48 @@ func (t *Texture) Texture() *webgl.Texture {
 	return t.id
 }
 
+// SetFilter sets the filtering used when the texture is scaled down (min) and up (mag), i.e. Gl.NEAREST for
+// pixel art. Textures which have been packed into an atlas use the filtering of the atlas.
+func (t *Texture) SetFilter(min, mag int) {
+	if t.id == nil || t.atlas != nil || headless {
+		return
+	}
+	Gl.BindTexture(Gl.TEXTURE_2D, t.id)
+	Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_MIN_FILTER, min)
+	Gl.TexParameteri(Gl.TEXTURE_2D, Gl.TEXTURE_MAG_FILTER, mag)
+}
+
+// Delete deletes the GL texture, or leaves the atlas it was packed into; the Texture can't be drawn anymore
+// afterwards
+func (t *Texture) Delete() {
+	if t.owner != nil {
+		t.owner = nil
+	} else if t.atlas != nil {
+		t.atlas.release()
+		t.atlas = nil
+	} else if t.id != nil && !headless {
+		Gl.DeleteTexture(t.id)
+	}
+	t.id = nil
+	t.image = nil
+	t.source = nil
+}
+
+// pixels returns the CPU copy of the texture, or reads its pixels from its source again
+func (t *Texture) pixels() (Image, error) {
+	if t.image != nil {
+		return t.image, nil
+	}
+	if t.source == nil {
+		return nil, fmt.Errorf("the pixels of the texture aren't available")
+	}
+	return t.source()
+}
+
+// View returns the part of the GL texture the Texture is drawn from, which is all of it, unless the texture
+// has been packed into an atlas
 func (r *Texture) View() (float32, float32, float32, float32) {
-	return 0.0, 0.0, 1.0, 1.0
+	return r.u, r.v, r.u2, r.v2
 }
 
 type Sprite struct {
@@ -0,0 +1,224 @@
+package engi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "engi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadErrors(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	dir := writeFiles(t, map[string]string{
+		"data.json":  `{}`,
+		"broken.png": "not a png",
+		"broken.tmx": "<map",
+	})
+	defer os.RemoveAll(dir)
+
+	if err := Files.AddFromDir(dir, false); err != nil {
+		t.Fatalf("adding an existing directory should succeed, not %v", err)
+	}
+
+	err := Files.Load(func() {})
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("load should return a *LoadError, not %v", err)
+	}
+	if len(loadErr.Errors) != 2 {
+		t.Errorf("2 resources should have failed, not %v", loadErr.Errors)
+	}
+	for _, name := range []string{"broken.png", "broken.tmx"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error should list %s, but was %q", name, err.Error())
+		}
+	}
+
+	if _, err := Files.GetJson("data.json"); err != nil {
+		t.Errorf("data.json should be loaded, not %v", err)
+	}
+	if _, err := Files.GetImage("broken.png"); !errors.Is(err, ErrMissingAsset) {
+		t.Errorf("broken.png should be a missing asset, not %v", err)
+	}
+	if _, err := Files.GetLevel("broken.tmx"); !errors.Is(err, ErrMissingAsset) {
+		t.Errorf("broken.tmx should be a missing asset, not %v", err)
+	}
+}
+
+func TestAddFromMissingDir(t *testing.T) {
+	Files = NewLoader()
+
+	if err := Files.AddFromDir(filepath.Join(os.TempDir(), "engi-does-not-exist"), true); err == nil {
+		t.Error("adding a missing directory should fail")
+	}
+}
+
+func TestStrictLoading(t *testing.T) {
+	resetScenes()
+
+	dir := writeFiles(t, map[string]string{"broken.png": "not a png"})
+	defer os.RemoveAll(dir)
+
+	Files.Strict = true
+	game := &loadScene{stackScene: stackScene{name: "strict"}, urls: []string{filepath.Join(dir, "broken.png")}}
+
+	var failed []SceneLoadFailedMessage
+	sub := Listen(Bus, func(msg SceneLoadFailedMessage) { failed = append(failed, msg) })
+	defer sub.Unsubscribe()
+
+	SetScene(game, false)
+	if game.loaded {
+		t.Error("setup should not be called in strict mode")
+	}
+	if len(failed) != 1 || failed[0].Scene != game || len(failed[0].Err.Errors) != 1 {
+		t.Errorf("the failed scene should be reported on the bus once, not %v", failed)
+	}
+	var loadErr *LoadError
+	if err := Files.Loading().Err(); !errors.As(err, &loadErr) || loadErr.Errors[0].URL != filepath.Join(dir, "broken.png") {
+		t.Errorf("the loading of the scene should report broken.png, not %v", err)
+	}
+	if len(game.errs) != 1 {
+		t.Errorf("the scene should be told about broken.png, not %v", game.errs)
+	}
+}
+
+func TestFirstLoading(t *testing.T) {
+	Files = NewLoader()
+
+	dir := writeFiles(t, map[string]string{"data.json": `{}`})
+	defer os.RemoveAll(dir)
+
+	Files.Add(filepath.Join(dir, "data.json"))
+	ld := Files.LoadAsync()
+	ld.Wait()
+	if resources := ld.Resources(); len(resources) != 1 || resources[0].Name != filepath.Join(dir, "data.json") {
+		t.Errorf("the first loading should only have data.json, not %v", resources)
+	}
+}
+
+type dialogue struct {
+	lines []string
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	RegisterDecoder("dialogue", func(r Resource) (*dialogue, error) {
+		b, err := ioutil.ReadFile(r.URL())
+		if err != nil {
+			return nil, err
+		}
+		return &dialogue{strings.Split(string(b), "\n")}, nil
+	})
+	defer delete(loaders, "dialogue")
+
+	dir := writeFiles(t, map[string]string{
+		"intro.dialogue": "hello\nworld",
+		"data.json":      `{}`,
+	})
+	defer os.RemoveAll(dir)
+
+	Files.AddFromDir(dir, false)
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatalf("loading should succeed, not %v", err)
+	}
+
+	d, err := Get[*dialogue](Files, "intro.dialogue")
+	if err != nil {
+		t.Fatalf("intro.dialogue should be loaded, not %v", err)
+	}
+	if len(d.lines) != 2 || d.lines[1] != "world" {
+		t.Errorf("intro.dialogue should have 2 lines, not %v", d.lines)
+	}
+
+	if json, err := Get[string](Files, "data.json"); err != nil || json != `{}` {
+		t.Errorf("data.json should be available through Get as well, not %q (%v)", json, err)
+	}
+	if _, err := Get[*Texture](Files, "intro.dialogue"); !errors.Is(err, ErrMissingAsset) {
+		t.Errorf("getting an asset of the wrong type should be a missing asset, not %v", err)
+	}
+	if _, err := Get[*dialogue](Files, "outro.dialogue"); !errors.Is(err, ErrMissingAsset) {
+		t.Errorf("outro.dialogue should be a missing asset, not %v", err)
+	}
+}
+
+func TestSceneAssets(t *testing.T) {
+	resetScenes()
+
+	var unloaded []string
+	RegisterLoader("blob", AssetLoader{
+		Decode: func(r Resource) (interface{}, error) { return ioutil.ReadFile(r.URL()) },
+		Unload: func(asset interface{}) { unloaded = append(unloaded, string(asset.([]byte))) },
+		Size:   func(asset interface{}) int { return len(asset.([]byte)) },
+	})
+	defer delete(loaders, "blob")
+
+	dir := writeFiles(t, map[string]string{
+		"shared.blob": "shared",
+		"menu.blob":   "menu",
+		"game.blob":   "game!",
+	})
+	defer os.RemoveAll(dir)
+
+	menu := &loadScene{stackScene: stackScene{name: "menu"}, urls: []string{
+		filepath.Join(dir, "shared.blob"), filepath.Join(dir, "menu.blob"),
+	}}
+	game := &loadScene{stackScene: stackScene{name: "game"}, urls: []string{
+		filepath.Join(dir, "shared.blob"), filepath.Join(dir, "game.blob"),
+	}}
+
+	SetScene(menu, false)
+	SetScene(game, false)
+
+	if err := DestroyScene(game); err == nil {
+		t.Error("destroying the current scene should fail")
+	}
+	if err := DestroyScene(menu); err != nil {
+		t.Fatalf("destroying the menu should succeed, not %v", err)
+	}
+	if len(unloaded) != 1 || unloaded[0] != "menu" {
+		t.Errorf("only menu.blob should be unloaded, not %v", unloaded)
+	}
+
+	report := Files.MemoryReport()
+	if len(report) != 2 {
+		t.Fatalf("2 assets should be loaded, not %v", report)
+	}
+	if report[0].Name != filepath.Join(dir, "shared.blob") || report[0].Size != 6 || report[0].Refs != 1 {
+		t.Errorf("shared.blob should be first, with 6 bytes and 1 reference, not %+v", report[0])
+	}
+	if report[1].Name != filepath.Join(dir, "game.blob") || report[1].Size != 5 {
+		t.Errorf("game.blob should be second, with 5 bytes, not %+v", report[1])
+	}
+
+	if err := Files.Unload("game.blob"); err != nil {
+		t.Errorf("unloading game.blob should succeed, not %v", err)
+	}
+	if err := Files.Unload("game.blob"); !errors.Is(err, ErrMissingAsset) {
+		t.Errorf("unloading game.blob twice should be a missing asset, not %v", err)
+	}
+
+	shutdown()
+	if len(unloaded) != 3 || unloaded[2] != "shared" {
+		t.Errorf("shared.blob should be unloaded at shutdown, not %v", unloaded)
+	}
+}
@@ -0,0 +1,336 @@
+package engi
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// Atlas is a set of images which have been packed into a few large textures, the pages, so they can be drawn
+// without switching textures. The Textures of the images stay valid, and are drawn from the pages from then on.
+type Atlas struct {
+	Name  string
+	pages []*Texture
+
+	loader *Loader
+	// packed is the number of Textures that are drawn from the pages; the pages are deleted once it's 0
+	packed int
+}
+
+// AtlasOptions determine how images are packed into an Atlas
+type AtlasOptions struct {
+	// MaxSize is the maximum width and height of the pages
+	MaxSize int
+	// Padding is the number of transparent pixels between the images
+	Padding int
+	// Extrude is the number of times the edges of the images are repeated around them, which prevents
+	// neighbouring images from bleeding in when filtering
+	Extrude int
+}
+
+// DefaultAtlasOptions are suitable for most games
+var DefaultAtlasOptions = AtlasOptions{MaxSize: 2048, Padding: 2, Extrude: 1}
+
+// AtlasPackedMessage is dispatched on the Mailbox of every Scene which has been set up, after images have been
+// packed into an Atlas
+type AtlasPackedMessage struct {
+	Name string
+}
+
+func (AtlasPackedMessage) Type() string {
+	return "AtlasPackedMessage"
+}
+
+// Pages returns the textures the images have been packed into
+func (a *Atlas) Pages() []*Texture {
+	return a.pages
+}
+
+func (a *Atlas) release() {
+	if a.packed--; a.packed > 0 {
+		return
+	}
+
+	for _, page := range a.pages {
+		page.Delete()
+	}
+	a.pages = nil
+	delete(a.loader.atlases, a.Name)
+	logDebug(LogAssets, "deleted atlas", "name", a.Name)
+}
+
+// Atlas returns the Atlas with the given name, or nil
+func (l *Loader) Atlas(name string) *Atlas {
+	return l.atlases[name]
+}
+
+// PackAtlas packs the loaded images with the given names into a new Atlas, or all loaded images which haven't
+// been packed yet if names is nil. Files.Image keeps returning the same Textures, but they are drawn from the
+// pages of the Atlas, like Regions; so are the Regions of those Textures. The pages are deleted once all
+// images have been unloaded.
+func (l *Loader) PackAtlas(name string, names []string, opts AtlasOptions) (*Atlas, error) {
+	if _, ok := l.atlases[name]; ok {
+		return nil, fmt.Errorf("atlas already exists: %s", name)
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultAtlasOptions.MaxSize
+	}
+
+	if names == nil {
+		for key, a := range l.assets {
+			if tex, ok := a.data.(*Texture); ok && tex.atlas == nil && (tex.image != nil || tex.source != nil) {
+				names = append(names, key)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	textures := make([]*Texture, len(names))
+	sources := make([]image.Image, len(names))
+	for i, n := range names {
+		tex, err := l.GetImage(n)
+		if err != nil {
+			return nil, err
+		}
+		if tex.atlas != nil {
+			return nil, fmt.Errorf("image %s has been packed into atlas %s already", n, tex.atlas.Name)
+		}
+		var src image.Image
+		if img, err := tex.pixels(); err == nil {
+			src, _ = img.Data().(image.Image)
+		}
+		if src == nil {
+			return nil, fmt.Errorf("cannot read the pixels of image %s", n)
+		}
+		textures[i], sources[i] = tex, src
+	}
+
+	// Big images first, which packs tighter
+	order := make([]int, len(textures))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := sources[order[i]].Bounds(), sources[order[j]].Bounds()
+		if a.Dy() != b.Dy() {
+			return a.Dy() > b.Dy()
+		}
+		return a.Dx() > b.Dx()
+	})
+
+	atlas := &Atlas{Name: name, loader: l}
+	border := 2*opts.Extrude + opts.Padding
+
+	for len(order) > 0 {
+		packer := newSkyline(opts.MaxSize+opts.Padding, opts.MaxSize+opts.Padding)
+		positions := make(map[int]image.Point)
+		var remaining []int
+		usedW, usedH := 0, 0
+
+		for _, i := range order {
+			b := sources[i].Bounds()
+			x, y, ok := packer.insert(b.Dx()+border, b.Dy()+border)
+			if !ok {
+				if len(positions) == 0 && len(remaining) == 0 {
+					// Not even on an empty page
+					return nil, fmt.Errorf("image %s is too large for an atlas of %d pixels", names[i], opts.MaxSize)
+				}
+				remaining = append(remaining, i)
+				continue
+			}
+			positions[i] = image.Pt(x, y)
+			usedW = maxInt(usedW, x+b.Dx()+2*opts.Extrude)
+			usedH = maxInt(usedH, y+b.Dy()+2*opts.Extrude)
+		}
+
+		pixels := image.NewNRGBA(image.Rect(0, 0, pageSize(usedW, opts.MaxSize), pageSize(usedH, opts.MaxSize)))
+		for i, pos := range positions {
+			drawExtruded(pixels, sources[i], pos.Add(image.Pt(opts.Extrude, opts.Extrude)), opts.Extrude)
+		}
+		page := NewTexture(nrgbaImage{pixels})
+		atlas.pages = append(atlas.pages, page)
+
+		for i, pos := range positions {
+			textures[i].pack(atlas, page, pos.X+opts.Extrude, pos.Y+opts.Extrude)
+		}
+
+		order = remaining
+	}
+
+	l.atlases[name] = atlas
+	logDebug(LogAssets, "packed atlas", "name", name, "images", len(textures), "pages", len(atlas.pages))
+
+	dispatchToScenes(AtlasPackedMessage{name})
+	return atlas, nil
+}
+
+// pack makes the texture draw from the page of the atlas, at the given position
+func (t *Texture) pack(atlas *Atlas, page *Texture, x, y int) {
+	if t.id != nil && !headless {
+		Gl.DeleteTexture(t.id)
+	}
+
+	t.id = page.id
+	t.atlas = atlas
+	t.atlasX, t.atlasY = x, y
+	t.u, t.v = float32(x)/page.width, float32(y)/page.height
+	t.u2, t.v2 = float32(x)/page.width+t.width/page.width, float32(y)/page.height+t.height/page.height
+	atlas.packed++
+}
+
+// unpack makes the texture leave its atlas; it needs to be uploaded afterwards
+func (t *Texture) unpack() {
+	t.atlas.release()
+	t.atlas = nil
+	t.id = nil
+	t.u, t.v, t.u2, t.v2 = 0, 0, 1, 1
+}
+
+// drawExtruded draws the image at the position, and repeats its edges around it
+func drawExtruded(dst *image.NRGBA, src image.Image, at image.Point, extrude int) {
+	b := src.Bounds()
+	draw.Draw(dst, image.Rectangle{at, at.Add(b.Size())}, src, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	if extrude == 0 || w == 0 || h == 0 {
+		return
+	}
+
+	for y := -extrude; y < h+extrude; y++ {
+		for x := -extrude; x < w+extrude; x++ {
+			if x >= 0 && x < w && y >= 0 && y < h {
+				continue
+			}
+			nearest := dst.NRGBAAt(at.X+clampInt(x, 0, w-1), at.Y+clampInt(y, 0, h-1))
+			dst.SetNRGBA(at.X+x, at.Y+y, nearest)
+		}
+	}
+}
+
+// pageSize is the smallest power of two that fits the used pixels, unless that is larger than the maximum
+func pageSize(used, max int) int {
+	size := 1 << uint(math.Ceil(math.Log2(float64(maxInt(used, 1)))))
+	if size > max {
+		return max
+	}
+	return size
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// nrgbaImage is an Image of pixels in memory
+type nrgbaImage struct {
+	*image.NRGBA
+}
+
+func (i nrgbaImage) Data() interface{} {
+	return i.NRGBA
+}
+
+func (i nrgbaImage) Width() int {
+	return i.Rect.Dx()
+}
+
+func (i nrgbaImage) Height() int {
+	return i.Rect.Dy()
+}
+
+// skyline packs rectangles bottom-left first, keeping track of the top edge of what has been packed so far
+type skyline struct {
+	width, height int
+	segments      []skylineSegment
+}
+
+type skylineSegment struct {
+	x, y, w int
+}
+
+func newSkyline(width, height int) *skyline {
+	return &skyline{width, height, []skylineSegment{{0, 0, width}}}
+}
+
+// insert finds the place where the top of the rectangle ends up lowest, and reserves it
+func (s *skyline) insert(w, h int) (x, y int, ok bool) {
+	best := -1
+	bestTop, bestX := 0, 0
+	for i, seg := range s.segments {
+		y, fits := s.fit(i, w, h)
+		if !fits {
+			continue
+		}
+		if best < 0 || y+h < bestTop || y+h == bestTop && seg.x < bestX {
+			best, bestTop, bestX = i, y+h, seg.x
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+
+	x, y = s.segments[best].x, bestTop-h
+	s.add(best, skylineSegment{x, bestTop, w})
+	return x, y, true
+}
+
+// fit returns the height at which the rectangle fits, when its left edge is at segment i
+func (s *skyline) fit(i, w, h int) (int, bool) {
+	x := s.segments[i].x
+	if x+w > s.width {
+		return 0, false
+	}
+
+	y := 0
+	for j, remaining := i, w; remaining > 0; j++ {
+		if s.segments[j].y > y {
+			y = s.segments[j].y
+		}
+		remaining -= s.segments[j].w
+	}
+	return y, y+h <= s.height
+}
+
+// add inserts the segment at index i, shrinking or removing the segments it covers
+func (s *skyline) add(i int, seg skylineSegment) {
+	end := seg.x + seg.w
+
+	var after []skylineSegment
+	for _, old := range s.segments[i:] {
+		oldEnd := old.x + old.w
+		if oldEnd <= end {
+			continue
+		}
+		if old.x < end {
+			old.x, old.w = end, oldEnd-end
+		}
+		after = append(after, old)
+	}
+
+	segments := append(append(s.segments[:i:i], seg), after...)
+
+	// Merge neighbours at the same height
+	merged := segments[:1]
+	for _, next := range segments[1:] {
+		if last := &merged[len(merged)-1]; last.y == next.y {
+			last.w += next.w
+		} else {
+			merged = append(merged, next)
+		}
+	}
+	s.segments = merged
+}
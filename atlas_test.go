@@ -0,0 +1,139 @@
+package engi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+	"testing/fstest"
+)
+
+func colorPNG(t *testing.T, w, h int, c color.NRGBA) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPackAtlas(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	red := color.NRGBA{255, 0, 0, 255}
+	green := color.NRGBA{0, 255, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+
+	Files.Mount(fstest.MapFS{
+		"red.png":   {Data: colorPNG(t, 10, 20, red)},
+		"green.png": {Data: colorPNG(t, 30, 5, green)},
+		"blue.png":  {Data: colorPNG(t, 8, 8, blue)},
+		"huge.png":  {Data: colorPNG(t, 100, 100, blue)},
+	})
+	Files.Add("red.png", "green.png", "blue.png", "huge.png")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Files.PackAtlas("too small", []string{"huge.png"}, AtlasOptions{MaxSize: 64}); err == nil {
+		t.Error("packing an image larger than the atlas should fail")
+	}
+
+	// Outside of headless mode, the CPU copy is dropped once the image has been uploaded
+	Files.Image("green.png").image = nil
+	if size := imageSize(Files.Image("green.png")); size != 30*5*4 {
+		t.Errorf("an image without CPU copy should only count its texture, not %d bytes", size)
+	}
+
+	redRegion := NewRegion(Files.Image("red.png"), 0, 10, 10, 10)
+	ren := NewRenderComponent(redRegion, Point{1, 1}, "red")
+
+	atlas, err := Files.PackAtlas("sprites", []string{"red.png", "green.png", "blue.png"}, DefaultAtlasOptions)
+	if err != nil {
+		t.Fatalf("packing should succeed, not %v", err)
+	}
+	if len(atlas.Pages()) != 1 {
+		t.Fatalf("the images should fit on a single page, not %d", len(atlas.Pages()))
+	}
+	page := atlas.Pages()[0]
+	pixels := page.image.Data().(*image.NRGBA)
+
+	for name, c := range map[string]color.NRGBA{"red.png": red, "green.png": green, "blue.png": blue} {
+		tex := Files.Image(name)
+		if tex.atlas != atlas {
+			t.Errorf("%s should be drawn from the atlas", name)
+			continue
+		}
+
+		u, v, u2, v2 := tex.View()
+		x, y := int(u*page.Width()), int(v*page.Height())
+		if w, h := int((u2-u)*page.Width()+0.5), int((v2-v)*page.Height()+0.5); w != int(tex.Width()) || h != int(tex.Height()) {
+			t.Errorf("%s should be viewed at its own size %vx%v, not %dx%d", name, tex.Width(), tex.Height(), w, h)
+		}
+		if got := pixels.NRGBAAt(x, y); got != c {
+			t.Errorf("%s should be in the page at %d,%d, but found %v", name, x, y, got)
+		}
+		if got := pixels.NRGBAAt(x-1, y-1); got != c {
+			t.Errorf("the corner of %s should be extruded, but found %v", name, got)
+		}
+		if got := pixels.NRGBAAt(x-2, y); got == c {
+			t.Errorf("%s should be padded, but found %v", name, got)
+		}
+	}
+
+	// Regions follow their Texture into the atlas
+	u, v, _, v2 := redRegion.View()
+	ru, rv, _, rv2 := Files.Image("red.png").View()
+	if u != ru || v != rv+(rv2-rv)/2 || v2 != rv2 {
+		t.Errorf("the region should view the bottom half of red.png in the atlas, not %v,%v-%v", u, v, v2)
+	}
+	if !ren.stale() {
+		t.Error("the buffer of a region should be stale once its texture is packed")
+	}
+	ren.preloadTexture()
+	if ren.stale() {
+		t.Error("the buffer should be up to date once generated again")
+	}
+
+	if _, err := Files.PackAtlas("again", []string{"red.png"}, DefaultAtlasOptions); err == nil {
+		t.Error("packing an image twice should fail")
+	}
+
+	for _, name := range []string{"red.png", "green.png", "blue.png"} {
+		Files.Unload(name)
+	}
+	if Files.Atlas("sprites") != nil {
+		t.Error("the atlas should be deleted once all of its images are unloaded")
+	}
+}
+
+func TestSkyline(t *testing.T) {
+	s := newSkyline(64, 64)
+
+	var placed []image.Rectangle
+	for _, size := range []image.Point{{30, 20}, {30, 20}, {20, 10}, {4, 30}, {64, 10}, {50, 5}} {
+		x, y, ok := s.insert(size.X, size.Y)
+		if !ok {
+			t.Fatalf("%v should fit", size)
+		}
+		r := image.Rect(x, y, x+size.X, y+size.Y)
+		if !r.In(image.Rect(0, 0, 64, 64)) {
+			t.Errorf("%v should be inside the page", r)
+		}
+		for _, other := range placed {
+			if r.Overlaps(other) {
+				t.Errorf("%v should not overlap %v", r, other)
+			}
+		}
+		placed = append(placed, r)
+	}
+
+	if _, _, ok := s.insert(64, 64); ok {
+		t.Error("a rectangle as large as the page should not fit anymore")
+	}
+}
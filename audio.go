@@ -1,9 +1,9 @@
+//go:build !windows
 // +build !windows
+
 package engi
 
 import (
-	"log"
-
 	"github.com/paked/engi/ecs"
 	"golang.org/x/mobile/exp/audio/al"
 )
@@ -17,6 +17,8 @@ type AudioComponent struct {
 	File       string
 	Repeat     bool
 	Background bool
+	// TimeScaled indicates whether the playback speed should follow Time.Scale(); a scale of 0 pauses the sound
+	TimeScaled bool
 	player     *Player
 }
 
@@ -28,6 +30,8 @@ func (*AudioComponent) Type() string {
 type AudioSystem struct {
 	*ecs.System
 	HeightModifier float32
+
+	subscription *Subscription
 }
 
 func (AudioSystem) Type() string {
@@ -42,16 +46,14 @@ func (as *AudioSystem) New(*ecs.World) {
 	}
 
 	if err := al.OpenDevice(); err != nil {
-		log.Println("Error initializing AudioSystem:", err)
+		logError(LogAudio, "could not open audio device", "err", err)
 		return
 	}
 
-	Mailbox.Listen("CameraMessage", func(msg Message) {
-		_, ok := msg.(CameraMessage)
-		if !ok {
-			return
-		}
-
+	if as.subscription != nil {
+		as.subscription.Unsubscribe()
+	}
+	as.subscription = Listen(Mailbox, func(CameraMessage) {
 		// Hopefully not that much of an issue, when we receive it before the CameraSystem does
 		// TODO: but it is when the CameraMessage is not Incremental (i.e. the changes are big)
 		al.SetListenerPosition(al.Vector{cam.X() / Width(), cam.Y() / Height(), cam.Z() * as.HeightModifier})
@@ -66,6 +68,10 @@ func (as *AudioSystem) Update(entity *ecs.Entity, dt float32) {
 	}
 
 	if ac.player == nil {
+		if Files.Settings(ac.File).Loop {
+			ac.Repeat = true
+		}
+
 		f := Files.Sound(ac.File)
 		if f == nil {
 			return
@@ -74,11 +80,26 @@ func (as *AudioSystem) Update(entity *ecs.Entity, dt float32) {
 		var err error
 		ac.player, err = NewPlayer(f, 0, 0)
 		if err != nil {
-			log.Println("Error initializing AudioSystem:", err)
+			logError(LogAudio, "could not create player", "file", ac.File, "err", err)
 			return
 		}
 	}
 
+	if ac.TimeScaled {
+		if Time.Paused() {
+			if ac.player.State() == Playing {
+				ac.player.Pause()
+			}
+			return
+		}
+
+		if speed := float64(Time.Scale()); speed != ac.player.Speed() {
+			if err := ac.player.SetSpeed(speed); err != nil {
+				logError(LogAudio, "could not change speed", "file", ac.File, "err", err)
+			}
+		}
+	}
+
 	if ac.player.State() != Playing {
 		if ac.player.State() == Stopped {
 			if !ac.Repeat {
@@ -91,7 +112,7 @@ func (as *AudioSystem) Update(entity *ecs.Entity, dt float32) {
 
 		// Prepares if the track hasn't been buffered before.
 		if err := ac.player.prepare(ac.Background, 0, false); err != nil {
-			log.Println("Error initializing AudioSystem:", err)
+			logError(LogAudio, "could not prepare player", "file", ac.File, "err", err)
 			return
 		}
 
@@ -107,8 +107,15 @@ type Player struct {
 	prep      bool
 	bufs      []al.Buffer // buffers are created and queued to source during prepare.
 	sizeBytes int64       // size of the audio source
+	base      int64       // offset in the source at which the queued buffers start
+	converted bool        // whether the queued buffers have been converted from Stereo16 to Mono16
+	speed     float64     // playback speed, 0 is treated as 1
 }
 
+// alPitch is the AL_PITCH parameter of sources, which changes their speed and pitch without buffering them
+// again
+const alPitch = 0x1003
+
 // NewPlayer returns a new Player.
 // It initializes the underlying audio devices and the related resources.
 // If zero values are provided for format and sample rate values, the player
@@ -193,6 +200,9 @@ func (p *Player) prepare(background bool, offset int64, force bool) error {
 	}
 	p.mu.Unlock()
 
+	// Offsets in between the samples of a frame would swap the channels, or the bytes of samples
+	base := offset - offset%formatBytes[p.t.format]
+	offset = base
 	if p.t.hasHeader {
 		offset += headerSize
 	}
@@ -204,12 +214,13 @@ func (p *Player) prepare(background bool, offset int64, force bool) error {
 	// the existing buffers as buffers are processed.
 	buf := make([]byte, 128*1024)
 	size := offset
+	converted := !background && p.t.format == Stereo16
 	for {
 		n, err := p.t.src.Read(buf)
 		if n > 0 {
 			size += int64(n)
 			b := al.GenBuffers(1)
-			if !background && p.t.format == Stereo16 {
+			if converted {
 				inputBuffer := bytes.NewBuffer(buf)
 				outputBuffer := new(bytes.Buffer)
 				var left, right int16
@@ -242,6 +253,8 @@ func (p *Player) prepare(background bool, offset int64, force bool) error {
 		al.DeleteBuffers(p.bufs...)
 	}
 	p.sizeBytes = size
+	p.base = base
+	p.converted = converted
 	p.bufs = bufs
 	p.prep = true
 	if len(bufs) > 0 {
@@ -301,6 +314,38 @@ func (p *Player) Seek(background bool, offset time.Duration) error {
 	return lastErr()
 }
 
+// SetSpeed changes the playback speed, and thereby the pitch, of the player. A speed of 1 plays
+// the source at its original sample rate. The player keeps playing, or stays paused, at its current
+// position, so the speed can be changed every frame.
+func (p *Player) SetSpeed(speed float64) error {
+	if p == nil {
+		return nil
+	}
+	if speed <= 0 {
+		return errors.New("audio: speed should be larger than 0")
+	}
+
+	p.mu.Lock()
+	p.speed = speed
+	p.mu.Unlock()
+
+	p.source.Setf(alPitch, float32(speed))
+	return lastErr()
+}
+
+// Speed returns the current playback speed of the player.
+func (p *Player) Speed() float64 {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.speed == 0 {
+		return 1
+	}
+	return p.speed
+}
+
 // Current returns the current playback position of the audio that is being played.
 func (p *Player) Current() time.Duration {
 	if p == nil {
@@ -308,7 +353,14 @@ func (p *Player) Current() time.Duration {
 	}
 	// TODO(jbd): Current never returns the Total when the playing is finished.
 	// OpenAL may be returning the last buffer's start point as an OffsetByte.
-	return byteOffsetToDur(p.t, int64(p.source.OffsetByte()))
+	played := int64(p.source.OffsetByte())
+	if p.converted {
+		// The buffers hold half as many bytes as the source
+		played *= 2
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return byteOffsetToDur(p.t, p.base+played)
 }
 
 // Total returns the total duration of the audio source.
@@ -1,8 +1,6 @@
 package engi
 
 import (
-	"log"
-
 	"github.com/paked/engi/ecs"
 )
 
@@ -15,6 +13,8 @@ type AudioComponent struct {
 	File       string
 	Repeat     bool
 	Background bool
+	// TimeScaled indicates whether the playback speed should follow Time.Scale(); a scale of 0 pauses the sound
+	TimeScaled bool
 	player     *Player
 }
 
@@ -35,7 +35,7 @@ func (AudioSystem) Type() string {
 func (as *AudioSystem) New(*ecs.World) {
 	as.System = ecs.NewSystem()
 
-	log.Println("Warning: audio is not yet implemented on Windows")
+	logWarn(LogAudio, "audio is not yet implemented on Windows")
 }
 
 func (as *AudioSystem) Update(entity *ecs.Entity, dt float32) {}
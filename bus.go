@@ -0,0 +1,131 @@
+package engi
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Bus is the MessageManager that persists across Scenes, unlike Mailbox, which belongs to the current Scene.
+// It is meant for global concerns like achievements or analytics, which would otherwise have to listen again
+// in every Scene. The Scene lifecycle messages are dispatched on it.
+var Bus = &MessageManager{}
+
+var (
+	forwardMu sync.RWMutex
+	// toBus counts the ForwardToBus rules per message type
+	toBus = make(map[string]int)
+)
+
+// ForwardingRule forwards Messages of a single type between the Bus and the Mailbox of the Scenes
+type ForwardingRule struct {
+	messageType string
+	sub         *Subscription
+	active      atomic.Bool
+}
+
+// ForwardToBus dispatches Messages of the given type on the Bus as well, whenever they are dispatched on the
+// Mailbox of any Scene
+func ForwardToBus(messageType string) *ForwardingRule {
+	r := &ForwardingRule{messageType: messageType}
+	r.active.Store(true)
+
+	forwardMu.Lock()
+	toBus[messageType]++
+	forwardMu.Unlock()
+
+	return r
+}
+
+// ForwardToScene dispatches Messages of the given type on the Mailbox of the current Scene as well, whenever
+// they are dispatched on the Bus
+func ForwardToScene(messageType string) *ForwardingRule {
+	r := &ForwardingRule{messageType: messageType}
+	r.active.Store(true)
+
+	r.sub = Bus.subscribe(&Subscription{
+		messageType: messageType,
+		forwarder:   true,
+		handler: func(msg Message) {
+			if Mailbox != nil {
+				Mailbox.dispatch(msg, false)
+			}
+		},
+	})
+
+	return r
+}
+
+// Remove stops forwarding Messages
+func (r *ForwardingRule) Remove() {
+	if !r.active.CompareAndSwap(true, false) {
+		return
+	}
+
+	if r.sub != nil {
+		r.sub.Unsubscribe()
+		return
+	}
+
+	forwardMu.Lock()
+	if toBus[r.messageType]--; toBus[r.messageType] <= 0 {
+		delete(toBus, r.messageType)
+	}
+	forwardMu.Unlock()
+}
+
+func forwardsToBus(messageType string) bool {
+	forwardMu.RLock()
+	forward := toBus[messageType] > 0
+	forwardMu.RUnlock()
+	return forward
+}
+
+// SceneShownMessage is dispatched on the Bus after a Scene has been shown
+type SceneShownMessage struct {
+	Scene Scene
+}
+
+func (SceneShownMessage) Type() string {
+	return "SceneShownMessage"
+}
+
+// SceneHiddenMessage is dispatched on the Bus after a Scene has been hidden
+type SceneHiddenMessage struct {
+	Scene Scene
+}
+
+func (SceneHiddenMessage) Type() string {
+	return "SceneHiddenMessage"
+}
+
+// SceneDestroyedMessage is dispatched on the Bus before the World of a Scene is thrown away, either because
+// it is recreated, or because the game loop has stopped
+type SceneDestroyedMessage struct {
+	Scene Scene
+}
+
+func (SceneDestroyedMessage) Type() string {
+	return "SceneDestroyedMessage"
+}
+
+// SceneLoadFailedMessage is dispatched on the Bus when some of the resources of a Scene could not be loaded,
+// right before it is set up. In Strict mode, the Scene is not set up, and is shown with an empty World until
+// the game switches to another Scene.
+type SceneLoadFailedMessage struct {
+	Scene Scene
+	Err   *LoadError
+}
+
+func (SceneLoadFailedMessage) Type() string {
+	return "SceneLoadFailedMessage"
+}
+
+func showScene(s Scene) {
+	s.Show()
+	Bus.Dispatch(SceneShownMessage{s})
+}
+
+func hideScene(s Scene) {
+	s.Hide()
+	Bus.Dispatch(SceneHiddenMessage{s})
+}
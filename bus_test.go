@@ -0,0 +1,82 @@
+package engi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSceneLifecycleMessages(t *testing.T) {
+	resetScenes()
+
+	var events []string
+	record := func(event string) func(Message) {
+		return func(msg Message) {
+			var s Scene
+			switch m := msg.(type) {
+			case SceneShownMessage:
+				s = m.Scene
+			case SceneHiddenMessage:
+				s = m.Scene
+			case SceneDestroyedMessage:
+				s = m.Scene
+			}
+			events = append(events, event+":"+s.Type())
+		}
+	}
+	subs := []*Subscription{
+		Bus.Listen("SceneShownMessage", record("shown")),
+		Bus.Listen("SceneHiddenMessage", record("hidden")),
+		Bus.Listen("SceneDestroyedMessage", record("destroyed")),
+	}
+	defer func() {
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+	}()
+
+	game := &stackScene{name: "game"}
+	SetScene(game, false)
+	PushScene(&stackScene{name: "pause"}, UnderlayVisible, nil)
+	PopScene(nil)
+	SetScene(game, true)
+
+	expected := "shown:game hidden:game shown:pause hidden:pause shown:game destroyed:pause hidden:game destroyed:game shown:game"
+	if got := strings.Join(events, " "); got != expected {
+		t.Errorf("lifecycle messages should be %q, not %q", expected, got)
+	}
+}
+
+func TestForwarding(t *testing.T) {
+	resetScenes()
+	SetScene(&stackScene{name: "forwarding"}, false)
+
+	var onBus, inScene int
+	busSub := Bus.Listen("testMessage", func(Message) { onBus++ })
+	defer busSub.Unsubscribe()
+	Mailbox.Listen("testMessage", func(Message) { inScene++ })
+
+	Mailbox.Dispatch(testMessage{})
+	if onBus != 0 {
+		t.Errorf("messages should not be forwarded without a rule, bus received %d", onBus)
+	}
+
+	toBus := ForwardToBus("testMessage")
+	toScene := ForwardToScene("testMessage")
+
+	Mailbox.Dispatch(testMessage{})
+	Bus.Dispatch(testMessage{})
+
+	if onBus != 2 || inScene != 3 {
+		t.Errorf("each message should be forwarded exactly once, bus received %d and scene %d", onBus, inScene)
+	}
+
+	toBus.Remove()
+	toScene.Remove()
+
+	Mailbox.Dispatch(testMessage{})
+	Bus.Dispatch(testMessage{})
+
+	if onBus != 3 || inScene != 4 {
+		t.Errorf("removed rules should not forward, bus received %d and scene %d", onBus, inScene)
+	}
+}
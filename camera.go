@@ -17,6 +17,8 @@ type cameraSystem struct {
 	*ecs.System
 	x, y, z  float32
 	tracking *ecs.Entity // The entity that is currently being followed
+
+	subscription *Subscription
 }
 
 func (cameraSystem) Type() string {
@@ -32,12 +34,10 @@ func (cam *cameraSystem) New(*ecs.World) {
 
 	cam.AddEntity(ecs.NewEntity([]string{cam.Type()}))
 
-	Mailbox.Listen("CameraMessage", func(msg Message) {
-		cammsg, ok := msg.(CameraMessage)
-		if !ok {
-			return
-		}
-
+	if cam.subscription != nil {
+		cam.subscription.Unsubscribe()
+	}
+	cam.subscription = Listen(Mailbox, func(cammsg CameraMessage) {
 		if cammsg.Incremental {
 			switch cammsg.Axis {
 			case XAxis:
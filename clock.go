@@ -6,16 +6,20 @@ import (
 )
 
 type Clock struct {
-	elapsed float64
-	delta   float64
-	fps     float64
-	frames  uint64
-	start   time.Time
-	frame   time.Time
+	elapsed  float64
+	delta    float64
+	unscaled float64
+	scale    float64
+	gameTime float64
+	fps      float64
+	frames   uint64
+	start    time.Time
+	frame    time.Time
 }
 
 func NewClock() *Clock {
 	clock := new(Clock)
+	clock.scale = 1
 	clock.start = time.Now()
 	clock.Tick()
 	return clock
@@ -25,10 +29,13 @@ func (c *Clock) Tick() {
 	now := time.Now()
 	c.frames += 1
 	if !c.frame.IsZero() {
-		c.delta = now.Sub(c.frame).Seconds()
+		c.unscaled = now.Sub(c.frame).Seconds()
 	}
 
-	c.elapsed += c.delta
+	c.delta = c.unscaled * c.scale
+	c.gameTime += c.delta
+
+	c.elapsed += c.unscaled
 	c.frame = now
 
 	if c.elapsed >= 1 {
@@ -38,14 +45,54 @@ func (c *Clock) Tick() {
 	}
 }
 
+// setUnscaledDelta overrides the time difference of the current frame, i.e. when replaying input
+func (c *Clock) setUnscaledDelta(d float64) {
+	c.gameTime -= c.delta
+	c.unscaled = d
+	c.delta = d * c.scale
+	c.gameTime += c.delta
+}
+
+// Delta returns the time difference in seconds since the last frame, multiplied by the time scale
 func (c *Clock) Delta() float32 {
 	return float32(c.delta)
 }
 
+// UnscaledDelta returns the real time difference in seconds since the last frame, regardless of the time scale.
+// This is what HUD and menu Systems should use, so they keep running while the game is paused.
+func (c *Clock) UnscaledDelta() float32 {
+	return float32(c.unscaled)
+}
+
 func (c *Clock) Fps() float32 {
 	return float32(c.fps)
 }
 
+// Time returns the real time in seconds since the Clock was created
 func (c *Clock) Time() float32 {
 	return float32(time.Now().Sub(c.start).Seconds())
 }
+
+// GameTime returns the scaled time in seconds that has passed in-game; it does not advance while paused
+func (c *Clock) GameTime() float32 {
+	return float32(c.gameTime)
+}
+
+// SetScale sets the time scale: 1 is normal speed, 0.25 is slow motion and 0 pauses the game.
+// Negative values are treated as 0.
+func (c *Clock) SetScale(scale float32) {
+	if scale < 0 {
+		scale = 0
+	}
+	c.scale = float64(scale)
+}
+
+// Scale returns the current time scale
+func (c *Clock) Scale() float32 {
+	return float32(c.scale)
+}
+
+// Paused indicates whether or not the time scale is 0
+func (c *Clock) Paused() bool {
+	return c.scale == 0
+}
@@ -0,0 +1,46 @@
+package engi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockScale(t *testing.T) {
+	c := NewClock()
+	c.SetScale(0.5)
+
+	c.frame = time.Now().Add(-time.Second)
+	c.Tick()
+
+	if d := c.UnscaledDelta(); d < 1 || d > 1.1 {
+		t.Errorf("UnscaledDelta should be about 1, not %v", d)
+	}
+
+	if d := c.Delta(); d < 0.5 || d > 0.55 {
+		t.Errorf("Delta should be about 0.5, not %v", d)
+	}
+
+	if c.GameTime() != c.Delta() {
+		t.Errorf("GameTime should equal %v, not %v", c.Delta(), c.GameTime())
+	}
+}
+
+func TestClockPaused(t *testing.T) {
+	c := NewClock()
+	c.SetScale(-1)
+
+	if !c.Paused() {
+		t.Error("Clock should be paused when setting a negative scale")
+	}
+
+	c.frame = time.Now().Add(-time.Second)
+	c.Tick()
+
+	if c.Delta() != 0 {
+		t.Errorf("Delta should be 0 while paused, not %v", c.Delta())
+	}
+
+	if c.UnscaledDelta() == 0 {
+		t.Error("UnscaledDelta should not be 0 while paused")
+	}
+}
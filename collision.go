@@ -2,7 +2,6 @@ package engi
 
 import (
 	"github.com/paked/engi/ecs"
-	"log"
 	"math"
 )
 
@@ -65,10 +64,16 @@ func (collision CollisionMessage) Type() string {
 
 type CollisionSystem struct {
 	*ecs.System
+
+	world *ecs.World
+	// mailbox is the Mailbox of the Scene, which can't be read from the goroutines that update in parallel
+	mailbox *MessageManager
 }
 
-func (cs *CollisionSystem) New(*ecs.World) {
+func (cs *CollisionSystem) New(w *ecs.World) {
 	cs.System = ecs.NewSystem()
+	cs.world = w
+	cs.mailbox = Mailbox
 }
 
 func (cs *CollisionSystem) RunInParallel() bool {
@@ -128,7 +133,12 @@ func (cs *CollisionSystem) Update(entity *ecs.Entity, dt float32) {
 					space.Position.Y += mtd.Y
 				}
 
-				Mailbox.Dispatch(CollisionMessage{Entity: entity, To: other})
+				if cs.RunInParallel() && !cs.world.Serial() {
+					// Listeners shouldn't be called from multiple goroutines at once
+					cs.mailbox.Post(CollisionMessage{Entity: entity, To: other})
+				} else {
+					cs.mailbox.Dispatch(CollisionMessage{Entity: entity, To: other})
+				}
 			}
 		}
 	}
@@ -155,13 +165,17 @@ func MinimumTranslation(rect1 AABB, rect2 AABB) Point {
 	bottom := float64(rect2.Max.Y - rect1.Min.Y)
 
 	if left > 0 || right < 0 {
-		log.Println("Box aint intercepting")
+		if logEnabled(LogCollision, LogDebug) {
+			logDebug(LogCollision, "boxes are not intersecting", "a", rect1, "b", rect2)
+		}
 		return mtd
 		//box doesnt intercept
 	}
 
 	if top > 0 || bottom < 0 {
-		log.Println("Box aint intercepting")
+		if logEnabled(LogCollision, LogDebug) {
+			logDebug(LogCollision, "boxes are not intersecting", "a", rect1, "b", rect2)
+		}
 		return mtd
 		//box doesnt intercept
 	}
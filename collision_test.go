@@ -0,0 +1,32 @@
+package engi
+
+import (
+	"testing"
+
+	"github.com/paked/engi/ecs"
+)
+
+func TestCollisionUsesMailboxOfScene(t *testing.T) {
+	scene := &MessageManager{}
+	Mailbox = scene
+
+	w := &ecs.World{}
+	w.New()
+	w.AddSystem(&CollisionSystem{})
+	for i := 0; i < 2; i++ {
+		e := ecs.NewEntity([]string{"CollisionSystem"})
+		e.AddComponent(&SpaceComponent{Width: 10, Height: 10})
+		e.AddComponent(&CollisionComponent{Main: i == 0})
+		w.AddEntity(e)
+	}
+
+	var collisions int
+	Listen(scene, func(CollisionMessage) { collisions++ })
+
+	// Another Scene being updated shouldn't change where the collisions are sent to
+	Mailbox = &MessageManager{}
+	w.Update(1)
+	if collisions != 1 {
+		t.Errorf("collision should be dispatched right away on the mailbox of the scene, not %d times", collisions)
+	}
+}
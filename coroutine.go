@@ -0,0 +1,213 @@
+package engi
+
+import (
+	"iter"
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+// Script is a generator-style function which can be run as a Coroutine. It yields a Wait whenever it
+// should be paused, and is resumed on the game loop as soon as that Wait is over. Whenever yield returns
+// false, the Coroutine has been cancelled and the Script should return.
+//
+//	func intro(yield func(engi.Wait) bool) {
+//		// move here...
+//		if !yield(engi.WaitSeconds(time.Second)) {
+//			return
+//		}
+//		// show text...
+//		if !yield(engi.WaitForKey(engi.Space)) {
+//			return
+//		}
+//	}
+type Script func(yield func(Wait) bool)
+
+// Wait is yielded by a Script to indicate what it is waiting for
+type Wait interface {
+	// over is checked once per frame, and indicates whether or not the Script can be resumed
+	over(dt float32) bool
+}
+
+type secondsWait struct {
+	remaining float32
+}
+
+func (w *secondsWait) over(dt float32) bool {
+	w.remaining -= dt
+	return w.remaining <= 0
+}
+
+// WaitSeconds waits until d has passed in-game
+func WaitSeconds(d time.Duration) Wait {
+	return &secondsWait{float32(d.Seconds())}
+}
+
+type untilWait func() bool
+
+func (w untilWait) over(float32) bool {
+	return w()
+}
+
+// WaitUntil waits until the predicate returns true; it is checked once per frame
+func WaitUntil(predicate func() bool) Wait {
+	return untilWait(predicate)
+}
+
+// WaitFrame waits until the next frame
+func WaitFrame() Wait {
+	return WaitUntil(func() bool { return true })
+}
+
+// WaitForKey waits until the Key has been pressed
+func WaitForKey(k Key) Wait {
+	return WaitUntil(func() bool { return Keys.Get(k).JustPressed() })
+}
+
+// MessageWait waits until a Message of the given type has been dispatched to the Mailbox
+type MessageWait struct {
+	messageType string
+
+	// Message is the Message that was received, once the wait is over
+	Message Message
+}
+
+func (w *MessageWait) over(float32) bool {
+	return w.Message != nil
+}
+
+// WaitForMessage waits until a Message of the given type has been dispatched; the Message itself
+// can be read from the MessageWait afterwards
+func WaitForMessage(messageType string) *MessageWait {
+	return &MessageWait{messageType: messageType}
+}
+
+// Coroutine is a handle to a running Script
+type Coroutine struct {
+	next func() (Wait, bool)
+	stop func()
+
+	script    Script
+	wait      Wait
+	cancelled bool
+	done      bool
+}
+
+// Cancel stops the Coroutine; the Script will be resumed one last time, with yield returning false
+func (co *Coroutine) Cancel() {
+	co.cancelled = true
+}
+
+// Done indicates whether or not the Script has returned, or was cancelled
+func (co *Coroutine) Done() bool {
+	return co.done
+}
+
+// CoroutineSystem resumes the Coroutines of a Scene once per frame. Because it is driven by the (scaled) time
+// difference, Coroutines do not advance while the game is paused. Coroutines are cancelled whenever the World
+// of their Scene is recreated.
+type CoroutineSystem struct {
+	*ecs.System
+
+	coroutines []*Coroutine
+	pending    []*Coroutine
+	listening  map[string]bool
+}
+
+func (*CoroutineSystem) Type() string {
+	return "CoroutineSystem"
+}
+
+func (cs *CoroutineSystem) New(*ecs.World) {
+	cs.System = ecs.NewSystem()
+	cs.listening = make(map[string]bool)
+
+	cs.AddEntity(ecs.NewEntity([]string{cs.Type()}))
+}
+
+// Start runs the Script as a Coroutine; it is first resumed during the next Update
+func (cs *CoroutineSystem) Start(s Script) *Coroutine {
+	co := &Coroutine{script: s}
+	cs.pending = append(cs.pending, co)
+	return co
+}
+
+func (cs *CoroutineSystem) Update(entity *ecs.Entity, dt float32) {
+	for _, co := range cs.pending {
+		// The coroutine has to be created on the game loop, so it runs on the same OS thread
+		co.next, co.stop = iter.Pull(iter.Seq[Wait](co.script))
+		cs.coroutines = append(cs.coroutines, co)
+	}
+	cs.pending = cs.pending[:0]
+
+	running := cs.coroutines[:0]
+	for _, co := range cs.coroutines {
+		if co.cancelled {
+			cs.finish(co)
+			continue
+		}
+
+		if co.wait != nil && !co.wait.over(dt) {
+			running = append(running, co)
+			continue
+		}
+
+		wait, ok := co.next()
+		if !ok || co.cancelled {
+			cs.finish(co)
+			continue
+		}
+
+		co.wait = wait
+		if mw, ok := wait.(*MessageWait); ok {
+			cs.listen(mw.messageType)
+		}
+		running = append(running, co)
+	}
+
+	for i := len(running); i < len(cs.coroutines); i++ {
+		cs.coroutines[i] = nil
+	}
+	cs.coroutines = running
+}
+
+// listen makes sure we receive the Messages of the given type, so they can be passed on to MessageWaits
+func (cs *CoroutineSystem) listen(messageType string) {
+	if cs.listening[messageType] {
+		return
+	}
+	cs.listening[messageType] = true
+
+	Mailbox.Listen(messageType, func(msg Message) {
+		for _, co := range cs.coroutines {
+			if mw, ok := co.wait.(*MessageWait); ok && mw.messageType == messageType && mw.Message == nil {
+				mw.Message = msg
+			}
+		}
+	})
+}
+
+func (cs *CoroutineSystem) finish(co *Coroutine) {
+	if co.stop != nil {
+		co.stop()
+	}
+	co.done = true
+	co.cancelled = true
+}
+
+// cancelAll stops all Coroutines, including those that haven't started yet
+func (cs *CoroutineSystem) cancelAll() {
+	for _, co := range cs.coroutines {
+		cs.finish(co)
+	}
+	for _, co := range cs.pending {
+		cs.finish(co)
+	}
+	cs.coroutines = nil
+	cs.pending = nil
+}
+
+// StartCoroutine runs the Script as a Coroutine in the current Scene
+func StartCoroutine(s Script) *Coroutine {
+	return coroutines.Start(s)
+}
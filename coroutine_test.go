@@ -0,0 +1,111 @@
+package engi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+type testMessage struct{}
+
+func (testMessage) Type() string {
+	return "testMessage"
+}
+
+func newTestCoroutineSystem() (*CoroutineSystem, *ecs.World) {
+	Mailbox = &MessageManager{}
+
+	w := &ecs.World{}
+	w.New()
+
+	cs := &CoroutineSystem{}
+	w.AddSystem(cs)
+	return cs, w
+}
+
+func TestCoroutineWaits(t *testing.T) {
+	cs, w := newTestCoroutineSystem()
+
+	var (
+		steps    []string
+		received Message
+		ready    bool
+	)
+	co := cs.Start(func(yield func(Wait) bool) {
+		steps = append(steps, "start")
+		if !yield(WaitSeconds(time.Second)) {
+			return
+		}
+		steps = append(steps, "seconds")
+
+		mw := WaitForMessage("testMessage")
+		if !yield(mw) {
+			return
+		}
+		received = mw.Message
+		steps = append(steps, "message")
+
+		if !yield(WaitUntil(func() bool { return ready })) {
+			return
+		}
+		steps = append(steps, "until")
+	})
+
+	w.Update(0.5)
+	w.Update(0.4)
+	if len(steps) != 1 {
+		t.Fatalf("coroutine should be waiting for 1 second, got steps %v", steps)
+	}
+
+	w.Update(0.1)
+	w.Update(1)
+	if len(steps) != 2 {
+		t.Fatalf("coroutine should be waiting for a message, got steps %v", steps)
+	}
+
+	Mailbox.Dispatch(testMessage{})
+	w.Update(0)
+	if len(steps) != 3 || received == nil {
+		t.Fatalf("coroutine should have received the message, got steps %v", steps)
+	}
+
+	w.Update(1)
+	ready = true
+	w.Update(0)
+	if len(steps) != 4 {
+		t.Fatalf("coroutine should have finished, got steps %v", steps)
+	}
+
+	w.Update(0)
+	if !co.Done() {
+		t.Error("coroutine should be done after the script returned")
+	}
+}
+
+func TestCoroutineCancel(t *testing.T) {
+	cs, w := newTestCoroutineSystem()
+
+	resumed, cleanedUp := false, false
+	co := cs.Start(func(yield func(Wait) bool) {
+		if !yield(WaitSeconds(time.Second)) {
+			cleanedUp = true
+			return
+		}
+		resumed = true
+	})
+
+	w.Update(0)
+	co.Cancel()
+	w.Update(2)
+
+	if resumed {
+		t.Error("coroutine should not continue after being cancelled")
+	}
+	if !cleanedUp {
+		t.Error("yield should return false after cancelling")
+	}
+	if !co.Done() {
+		t.Error("coroutine should be done after being cancelled")
+	}
+}
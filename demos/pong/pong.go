@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/paked/engi"
 	"github.com/paked/engi/ecs"
@@ -89,17 +90,14 @@ type SpeedSystem struct {
 
 func (ms *SpeedSystem) New(*ecs.World) {
 	ms.System = ecs.NewSystem()
-	engi.Mailbox.Listen("CollisionMessage", func(message engi.Message) {
+	engi.Listen(engi.Mailbox, func(collision engi.CollisionMessage) {
 		log.Println("collision")
-		collision, isCollision := message.(engi.CollisionMessage)
-		if isCollision {
-			var speed *SpeedComponent
-			if !collision.Entity.Component(&speed) {
-				return
-			}
-
-			speed.X *= -1
+		var speed *SpeedComponent
+		if !collision.Entity.Component(&speed) {
+			return
 		}
+
+		speed.X *= -1
 	})
 }
 
@@ -148,11 +146,7 @@ func (bs *BallSystem) Update(entity *ecs.Entity, dt float32) {
 
 	if space.Position.X < 0 {
 		engi.Mailbox.Dispatch(ScoreMessage{1})
-
-		space.Position.X = 400 - 16
-		space.Position.Y = 400 - 16
-		speed.X = 800 * rand.Float32()
-		speed.Y = 800 * rand.Float32()
+		bs.respawn(entity, space, speed)
 	}
 
 	if space.Position.Y < 0 {
@@ -162,11 +156,7 @@ func (bs *BallSystem) Update(entity *ecs.Entity, dt float32) {
 
 	if space.Position.X > (800 - 16) {
 		engi.Mailbox.Dispatch(ScoreMessage{2})
-
-		space.Position.X = 400 - 16
-		space.Position.Y = 400 - 16
-		speed.X = 800 * rand.Float32()
-		speed.Y = 800 * rand.Float32()
+		bs.respawn(entity, space, speed)
 	}
 
 	if space.Position.Y > (800 - 16) {
@@ -175,6 +165,18 @@ func (bs *BallSystem) Update(entity *ecs.Entity, dt float32) {
 	}
 }
 
+// respawn puts the ball back in the middle, and serves it again after 2 seconds
+func (bs *BallSystem) respawn(entity *ecs.Entity, space *engi.SpaceComponent, speed *SpeedComponent) {
+	space.Position.X = 400 - 16
+	space.Position.Y = 400 - 16
+	speed.X, speed.Y = 0, 0
+
+	engi.After(2*time.Second, func() {
+		speed.X = 800 * rand.Float32()
+		speed.Y = 800 * rand.Float32()
+	}).Bind(entity)
+}
+
 type ControlSystem struct {
 	*ecs.System
 }
@@ -237,12 +239,7 @@ func (ScoreSystem) Type() string {
 func (sc *ScoreSystem) New(*ecs.World) {
 	sc.upToDate = true
 	sc.System = ecs.NewSystem()
-	engi.Mailbox.Listen("ScoreMessage", func(message engi.Message) {
-		scoreMessage, isScore := message.(ScoreMessage)
-		if !isScore {
-			return
-		}
-
+	engi.Listen(engi.Mailbox, func(scoreMessage ScoreMessage) {
 		sc.scoreLock.Lock()
 		if scoreMessage.Player != 1 {
 			sc.PlayerOneScore += 1
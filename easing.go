@@ -0,0 +1,100 @@
+package engi
+
+import (
+	"math"
+)
+
+// EaseFunc maps the linear progress t, in the range [0, 1], to the eased progress. Most easing functions
+// return 0 for t = 0 and 1 for t = 1, but may overshoot in between (i.e. EaseInBack and EaseOutElastic).
+type EaseFunc func(t float32) float32
+
+// These are the easing equations by Robert Penner, see http://robertpenner.com/easing/
+var (
+	EaseLinear EaseFunc = func(t float32) float32 { return t }
+
+	EaseInQuad    EaseFunc = func(t float32) float32 { return t * t }
+	EaseOutQuad   EaseFunc = func(t float32) float32 { return t * (2 - t) }
+	EaseInOutQuad          = easeInOut(EaseInQuad)
+
+	EaseInCubic    EaseFunc = func(t float32) float32 { return t * t * t }
+	EaseOutCubic            = easeOut(EaseInCubic)
+	EaseInOutCubic          = easeInOut(EaseInCubic)
+
+	EaseInQuart    EaseFunc = func(t float32) float32 { return t * t * t * t }
+	EaseOutQuart            = easeOut(EaseInQuart)
+	EaseInOutQuart          = easeInOut(EaseInQuart)
+
+	EaseInQuint    EaseFunc = func(t float32) float32 { return t * t * t * t * t }
+	EaseOutQuint            = easeOut(EaseInQuint)
+	EaseInOutQuint          = easeInOut(EaseInQuint)
+
+	EaseInSine    EaseFunc = func(t float32) float32 { return 1 - float32(math.Cos(float64(t)*math.Pi/2)) }
+	EaseOutSine   EaseFunc = func(t float32) float32 { return float32(math.Sin(float64(t) * math.Pi / 2)) }
+	EaseInOutSine EaseFunc = func(t float32) float32 { return float32(1-math.Cos(float64(t)*math.Pi)) / 2 }
+
+	EaseInExpo EaseFunc = func(t float32) float32 {
+		if t == 0 {
+			return 0
+		}
+		return float32(math.Pow(2, 10*(float64(t)-1)))
+	}
+	EaseOutExpo   = easeOut(EaseInExpo)
+	EaseInOutExpo = easeInOut(EaseInExpo)
+
+	EaseInCirc    EaseFunc = func(t float32) float32 { return 1 - float32(math.Sqrt(float64(1-t*t))) }
+	EaseOutCirc            = easeOut(EaseInCirc)
+	EaseInOutCirc          = easeInOut(EaseInCirc)
+
+	EaseInElastic EaseFunc = func(t float32) float32 {
+		if t == 0 || t == 1 {
+			return t
+		}
+		const period = 0.3
+		s := float64(t) - 1
+		return float32(-math.Pow(2, 10*s) * math.Sin((s-period/4)*(2*math.Pi)/period))
+	}
+	EaseOutElastic   = easeOut(EaseInElastic)
+	EaseInOutElastic = easeInOut(EaseInElastic)
+
+	EaseInBack EaseFunc = func(t float32) float32 {
+		const s = 1.70158
+		return t * t * ((s+1)*t - s)
+	}
+	EaseOutBack   = easeOut(EaseInBack)
+	EaseInOutBack = easeInOut(EaseInBack)
+
+	EaseOutBounce EaseFunc = func(t float32) float32 {
+		switch {
+		case t < 1/2.75:
+			return 7.5625 * t * t
+		case t < 2/2.75:
+			t -= 1.5 / 2.75
+			return 7.5625*t*t + 0.75
+		case t < 2.5/2.75:
+			t -= 2.25 / 2.75
+			return 7.5625*t*t + 0.9375
+		default:
+			t -= 2.625 / 2.75
+			return 7.5625*t*t + 0.984375
+		}
+	}
+	EaseInBounce    = easeOut(EaseOutBounce)
+	EaseInOutBounce = easeInOut(EaseInBounce)
+)
+
+// easeOut mirrors an ease-in function (or vice versa)
+func easeOut(in EaseFunc) EaseFunc {
+	return func(t float32) float32 {
+		return 1 - in(1-t)
+	}
+}
+
+// easeInOut uses the ease-in function for the first half, and its mirror for the second half
+func easeInOut(in EaseFunc) EaseFunc {
+	return func(t float32) float32 {
+		if t < 0.5 {
+			return in(t*2) / 2
+		}
+		return 1 - in((1-t)*2)/2
+	}
+}
@@ -40,6 +40,16 @@ type Systemer interface {
 	RemoveEntity(entity *Entity)
 }
 
+// UnscaledSystemer can optionally be implemented by a Systemer which should receive
+// the real, unscaled time difference in Update, instead of the scaled one. This is
+// useful for HUD or menu Systems, which should keep running while the game is paused
+// or in slow motion.
+type UnscaledSystemer interface {
+	Systemer
+	// Unscaled indicates whether or not the unscaled time difference should be used
+	Unscaled() bool
+}
+
 // System is the default implementation of the Systemer interface.
 type System struct {
 	EntityMap            map[string]*Entity
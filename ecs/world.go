@@ -15,6 +15,12 @@ type World struct {
 	serial  bool
 }
 
+// Serial indicates whether the Systems are updated one Entity after the other, even when they could run in
+// parallel
+func (w *World) Serial() bool {
+	return w.serial
+}
+
 // New initialises the World
 func (w *World) New() {
 	if w.isSetup {
@@ -84,6 +90,12 @@ func (w *World) Systems() []Systemer {
 	return w.systems
 }
 
+// HasEntity checks whether the Entity is part of the World
+func (w *World) HasEntity(entity *Entity) bool {
+	_, ok := w.entities[entity.ID()]
+	return ok
+}
+
 func (w *World) HasSystem(systemType string) bool {
 	for _, s := range w.systems {
 		if s.Type() == systemType {
@@ -95,8 +107,30 @@ func (w *World) HasSystem(systemType string) bool {
 
 // Update is called on each frame, with dt being the time difference in seconds since the last Update call
 func (w *World) Update(dt float32) {
+	w.UpdateScaled(dt, dt)
+}
+
+// UpdateScaled is called on each frame, with dt being the scaled time difference in seconds since the last
+// Update call, and unscaledDt the real time difference. Systems implementing UnscaledSystemer may opt-in to
+// receiving unscaledDt instead of dt.
+func (w *World) UpdateScaled(dt, unscaledDt float32) {
+	w.UpdateSystems(dt, unscaledDt, nil)
+}
+
+// UpdateSystems is like UpdateScaled, but only updates the Systems for which include returns true. If include
+// is nil, all Systems are updated.
+func (w *World) UpdateSystems(dt, unscaledDt float32, include func(Systemer) bool) {
 	complChan := make(chan struct{})
 	for _, system := range w.Systems() {
+		if include != nil && !include(system) {
+			continue
+		}
+
+		dt := dt
+		if u, ok := system.(UnscaledSystemer); ok && u.Unscaled() {
+			dt = unscaledDt
+		}
+
 		system.Pre()
 
 		entities := system.Entities()
@@ -58,3 +58,72 @@ func TestAddComponent(t *testing.T) {
 		t.Fail()
 	}
 }
+
+type deltaSystem struct {
+	*System
+	unscaled bool
+	lastDt   float32
+}
+
+func (ds *deltaSystem) New(*World) {
+	ds.System = NewSystem()
+}
+
+func (ds *deltaSystem) Type() string {
+	if ds.unscaled {
+		return "unscaledDeltaSystem"
+	}
+	return "deltaSystem"
+}
+
+func (ds *deltaSystem) Unscaled() bool {
+	return ds.unscaled
+}
+
+func (ds *deltaSystem) Update(e *Entity, dt float32) {
+	ds.lastDt = dt
+}
+
+func TestUpdateScaled(t *testing.T) {
+	world := World{}
+	world.New()
+
+	scaled := &deltaSystem{}
+	unscaled := &deltaSystem{unscaled: true}
+	world.AddSystem(scaled)
+	world.AddSystem(unscaled)
+	world.AddEntity(NewEntity([]string{"deltaSystem", "unscaledDeltaSystem"}))
+
+	world.UpdateScaled(0.25, 1)
+
+	if scaled.lastDt != 0.25 {
+		t.Errorf("scaled system should have received 0.25, not %v", scaled.lastDt)
+	}
+
+	if unscaled.lastDt != 1 {
+		t.Errorf("unscaled system should have received 1, not %v", unscaled.lastDt)
+	}
+}
+
+func TestUpdateSystems(t *testing.T) {
+	world := World{}
+	world.New()
+
+	scaled := &deltaSystem{}
+	unscaled := &deltaSystem{unscaled: true}
+	world.AddSystem(scaled)
+	world.AddSystem(unscaled)
+	world.AddEntity(NewEntity([]string{"deltaSystem", "unscaledDeltaSystem"}))
+
+	world.UpdateSystems(0.5, 0.5, func(s Systemer) bool {
+		return s == unscaled
+	})
+
+	if scaled.lastDt != 0 {
+		t.Errorf("excluded system should not have been updated, received %v", scaled.lastDt)
+	}
+
+	if unscaled.lastDt != 0.5 {
+		t.Errorf("included system should have received 0.5, not %v", unscaled.lastDt)
+	}
+}
@@ -2,6 +2,7 @@ package engi
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/paked/engi/ecs"
 	"github.com/paked/webgl"
@@ -15,14 +16,24 @@ var (
 
 	currentWorld *ecs.World
 	currentScene Scene
-	Mailbox      *MessageManager
-	cam          *cameraSystem
+	// Mailbox is the MessageManager of the Scene that is being updated. It is reassigned for every Scene on
+	// the stack, every frame, so it must only be read on the main thread; other goroutines should Post to a
+	// Mailbox that has been captured on the main thread, i.e. in Setup, or to the Bus.
+	Mailbox    *MessageManager
+	cam        *cameraSystem
+	scheduler  *Scheduler
+	coroutines *CoroutineSystem
 
 	scaleOnResize   = false
 	fpsLimit        = 120
 	headless        = false
 	vsync           = true
 	resetLoopTicker = make(chan bool, 1)
+
+	closing     bool
+	exitCode    int
+	maxFrames   int
+	maxDuration time.Duration
 )
 
 type RunOptions struct {
@@ -46,13 +57,24 @@ type RunOptions struct {
 
 	// FPSLimit indicates the maximum number of frames per second
 	FPSLimit int
+
+	// MaxFrames indicates the number of frames after which Open returns, 0 for no limit
+	MaxFrames int
+
+	// MaxDuration indicates the time after which Open returns, 0 for no limit
+	MaxDuration time.Duration
 }
 
-func Open(opts RunOptions, defaultScene Scene) {
+// Open runs the game loop until Exit is called, the window is closed, one of the limits in RunOptions is
+// reached, or the process receives SIGINT or SIGTERM. The current Scene is hidden before Open returns. It
+// returns the exit code given to ExitWithCode, or 128 plus the signal number when it was interrupted.
+func Open(opts RunOptions, defaultScene Scene) int {
 	// Save settings
 	SetScaleOnResize(opts.ScaleOnResize)
 	SetFPSLimit(opts.FPSLimit)
 	vsync = opts.VSync
+	maxFrames = opts.MaxFrames
+	maxDuration = opts.MaxDuration
 
 	if opts.HeadlessMode {
 		headless = true
@@ -68,6 +90,43 @@ func Open(opts RunOptions, defaultScene Scene) {
 			runLoop(defaultScene, false)
 		}
 	}
+
+	return exitCode
+}
+
+// Exit stops the game loop after the current frame, and makes Open return 0
+func Exit() {
+	ExitWithCode(0)
+}
+
+// ExitWithCode stops the game loop after the current frame, and makes Open return the given code
+func ExitWithCode(code int) {
+	exitCode = code
+	closing = true
+}
+
+// shouldStop indicates whether the game loop should stop, after the given number of frames since start
+func shouldStop(frames int, start time.Time) bool {
+	return closing ||
+		(maxFrames > 0 && frames >= maxFrames) ||
+		(maxDuration > 0 && time.Since(start) >= maxDuration)
+}
+
+// shutdown is called once the game loop has stopped; it hides the current Scene, destroys all Scenes and
+// flushes the input recording
+func shutdown() {
+	if currentScene != nil {
+		hideScene(currentScene)
+	}
+
+	for _, wrapper := range scenes {
+		Files.Release(wrapper.destroy()...)
+	}
+
+	if recorder != nil {
+		recorder.Close()
+		recorder = nil
+	}
 }
 
 func SetBg(color uint32) {
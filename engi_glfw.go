@@ -5,12 +5,12 @@ package engi
 import (
 	"image"
 	"image/draw"
-	_ "image/png"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/go-gl/glfw/v3.1/glfw"
@@ -176,7 +176,7 @@ func DestroyWindow() {
 
 func SetTitle(title string) {
 	if headless {
-		log.Println("Title set to:", title)
+		logInfo(LogEngine, "title set", "title", title)
 	} else {
 		window.SetTitle(title)
 	}
@@ -186,24 +186,17 @@ func runHeadless(defaultScene Scene) {
 	runLoop(defaultScene, true)
 }
 
+func pollEvents() {
+	glfw.PollEvents()
+}
+
 // RunIteration runs one iteration / frame
 func RunIteration() {
-	// First check for new keypresses
-	if !headless {
-		glfw.PollEvents()
-		keysUpdate()
-	}
-
-	// Then update the world and all Systems
-	currentWorld.Update(Time.Delta())
-
-	// Lastly, forget keypresses and swap buffers
-	if !headless {
-		Mouse.ScrollX, Mouse.ScrollY = 0, 0
-		window.SwapBuffers()
+	if headless {
+		runFrame(nil)
+	} else {
+		runFrame(window.SwapBuffers)
 	}
-
-	Time.Tick()
 }
 
 // RunPreparation is called only once, and is called automatically when calling Open
@@ -220,23 +213,42 @@ func RunPreparation(defaultScene Scene) {
 }
 
 func runLoop(defaultScene Scene, headless bool) {
+	closing, exitCode = false, 0
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
 	RunPreparation(defaultScene)
 
+	start := time.Now()
+	frames := 0
+
 	ticker := time.NewTicker(time.Duration(int(time.Second) / fpsLimit))
 Outer:
-	for {
+	for !closing {
 		select {
 		case <-ticker.C:
 			RunIteration()
-			if !headless && window.ShouldClose() {
+			frames++
+			if shouldStop(frames, start) || (!headless && window.ShouldClose()) {
 				break Outer
 			}
 		case <-resetLoopTicker:
 			ticker.Stop()
 			ticker = time.NewTicker(time.Duration(int(time.Second) / fpsLimit))
+		case sig := <-signals:
+			// A second signal terminates the process as usual
+			signal.Stop(signals)
+			if s, ok := sig.(syscall.Signal); ok {
+				exitCode = 128 + int(s)
+			}
+			break Outer
 		}
 	}
 	ticker.Stop()
+
+	shutdown()
 }
 
 func Width() float32 {
@@ -255,10 +267,6 @@ func WindowHeight() float32 {
 	return windowHeight
 }
 
-func Exit() {
-	window.SetShouldClose(true)
-}
-
 func SetCursor(c *glfw.Cursor) {
 	window.SetCursor(c)
 }
@@ -420,7 +428,7 @@ func (i *ImageObject) Height() int {
 }
 
 func loadImage(r Resource) (Image, error) {
-	file, err := os.Open(r.url)
+	file, err := r.Open()
 	if err != nil {
 		return nil, err
 	}
@@ -438,7 +446,7 @@ func loadImage(r Resource) (Image, error) {
 }
 
 func loadJSON(r Resource) (string, error) {
-	file, err := ioutil.ReadFile(r.url)
+	file, err := r.ReadAll()
 	if err != nil {
 		return "", err
 	}
@@ -446,7 +454,7 @@ func loadJSON(r Resource) (string, error) {
 }
 
 func loadFont(r Resource) (*truetype.Font, error) {
-	ttfBytes, err := ioutil.ReadFile(r.url)
+	ttfBytes, err := r.ReadAll()
 	if err != nil {
 		return nil, err
 	}
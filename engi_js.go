@@ -153,11 +153,13 @@ func height() float32 {
 
 func animate(dt float32) {
 	RequestAnimationFrame(animate)
-	responder.Update(Time.Delta())
-	Time.Tick()
-	keysUpdate()
+	// The browser presents the frame itself, once we return
+	runFrame(nil)
 }
 
+// pollEvents does nothing, because the browser calls our event listeners itself
+func pollEvents() {}
+
 func exit() {
 	responder.Close()
 }
@@ -0,0 +1,72 @@
+package engi
+
+import (
+	"testing"
+
+	"github.com/paked/engi/ecs"
+)
+
+type exitSystem struct {
+	*ecs.System
+	after int
+	code  int
+}
+
+func (s *exitSystem) New(*ecs.World) {
+	s.System = ecs.NewSystem()
+	s.AddEntity(ecs.NewEntity([]string{s.Type()}))
+}
+
+func (*exitSystem) Type() string {
+	return "exitSystem"
+}
+
+func (s *exitSystem) Update(*ecs.Entity, float32) {
+	s.after--
+	if s.after == 0 {
+		ExitWithCode(s.code)
+	}
+}
+
+type exitScene struct {
+	stackScene
+	exit *exitSystem
+}
+
+func (s *exitScene) Setup(w *ecs.World) {
+	s.stackScene.Setup(w)
+	if s.exit != nil {
+		w.AddSystem(s.exit)
+	}
+}
+
+func TestOpenMaxFrames(t *testing.T) {
+	resetScenes()
+
+	scene := &exitScene{stackScene: stackScene{name: "maxFrames"}}
+	code := Open(RunOptions{HeadlessMode: true, MaxFrames: 3}, scene)
+
+	if code != 0 {
+		t.Errorf("exit code should be 0, not %d", code)
+	}
+	if scene.count.updates != 3 {
+		t.Errorf("scene should be updated 3 times, not %d", scene.count.updates)
+	}
+	if n := len(scene.events); n == 0 || scene.events[n-1] != "hide" {
+		t.Errorf("scene should be hidden before Open returns, events were %v", scene.events)
+	}
+}
+
+func TestOpenExit(t *testing.T) {
+	resetScenes()
+
+	scene := &exitScene{stackScene: stackScene{name: "exit"}, exit: &exitSystem{after: 2, code: 3}}
+	code := Open(RunOptions{HeadlessMode: true, MaxFrames: 10}, scene)
+
+	if code != 3 {
+		t.Errorf("exit code should be 3, not %d", code)
+	}
+	if scene.count.updates != 2 {
+		t.Errorf("scene should be updated 2 times, not %d", scene.count.updates)
+	}
+}
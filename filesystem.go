@@ -0,0 +1,145 @@
+package engi
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+)
+
+// Mount adds the file system on top of those which have been mounted before, so its files override theirs;
+// i.e. mount the game's embed.FS first, and then the directories or archives of mods. Once anything has been
+// mounted, resources are only read from the mounted file systems, rather than from the OS.
+func (l *Loader) Mount(fsys fs.FS) {
+	l.mounts = append(l.mounts, fsys)
+}
+
+// MountZip mounts the zip archive (or a pak file in zip format) at the given path on the OS; the archive
+// stays open for as long as the program runs
+func (l *Loader) MountZip(name string) error {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	l.Mount(archive)
+	return nil
+}
+
+// FS returns the file system resources are read from, which combines all mounted file systems
+func (l *Loader) FS() fs.FS {
+	if len(l.mounts) == 0 {
+		return osFS{}
+	}
+	return overlayFS(append([]fs.FS(nil), l.mounts...))
+}
+
+// Open opens the file from the file system of the Loader
+func (l *Loader) Open(url string) (fs.File, error) {
+	return l.FS().Open(url)
+}
+
+// ReadFile reads the file from the file system of the Loader
+func (l *Loader) ReadFile(url string) ([]byte, error) {
+	return fs.ReadFile(l.FS(), url)
+}
+
+// Open opens the file of the resource, from the file system of the Loader it is loaded by
+func (r Resource) Open() (fs.File, error) {
+	return r.fs().Open(r.url)
+}
+
+// ReadAll reads the file of the resource, from the file system of the Loader it is loaded by
+func (r Resource) ReadAll() ([]byte, error) {
+	return fs.ReadFile(r.fs(), r.url)
+}
+
+func (r Resource) fs() fs.FS {
+	if r.fsys == nil {
+		return osFS{}
+	}
+	return r.fsys
+}
+
+// osFS reads files from the OS as is, unlike os.DirFS, so existing relative and absolute paths keep working
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// overlayFS combines file systems, where those at the end override the ones before them
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	name = path.Clean(name)
+
+	err := error(&fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist})
+	for i := len(o) - 1; i >= 0; i-- {
+		f, openErr := o[i].Open(name)
+		if openErr == nil {
+			return f, nil
+		}
+		if !errors.Is(openErr, fs.ErrNotExist) {
+			err = openErr
+		}
+	}
+	return nil, err
+}
+
+// ReadDir merges the directory of all file systems which have it
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = path.Clean(name)
+
+	var (
+		entries []fs.DirEntry
+		seen    = make(map[string]bool)
+		found   bool
+		err     error
+	)
+	for i := len(o) - 1; i >= 0; i-- {
+		dir, readErr := fs.ReadDir(o[i], name)
+		if readErr != nil {
+			err = readErr
+			continue
+		}
+		found = true
+		for _, e := range dir {
+			if !seen[e.Name()] {
+				seen[e.Name()] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+	if !found {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// readSeekCloser makes the file seekable, by reading it into memory if needed; files in zip archives can't seek
+func readSeekCloser(f fs.File) (ReadSeekCloser, error) {
+	if rsc, ok := f.(ReadSeekCloser); ok {
+		return rsc, nil
+	}
+
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
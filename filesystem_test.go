@@ -0,0 +1,81 @@
+package engi
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMountOverlay(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	Files.Mount(fstest.MapFS{
+		"data/tuning.json": {Data: []byte(`{"speed": 1}`)},
+		"data/items.json":  {Data: []byte(`[]`)},
+	})
+	Files.Mount(fstest.MapFS{
+		"data/tuning.json": {Data: []byte(`{"speed": 2}`)},
+		"data/extra.json":  {Data: []byte(`{}`)},
+	})
+
+	if err := Files.AddFromDir("data", false); err != nil {
+		t.Fatalf("adding the merged directory should succeed, not %v", err)
+	}
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatalf("loading should succeed, not %v", err)
+	}
+
+	if json := Files.Json("tuning.json"); json != `{"speed": 2}` {
+		t.Errorf("tuning.json should be overridden by the mod, not %q", json)
+	}
+	for _, name := range []string{"items.json", "extra.json"} {
+		if _, err := Files.GetJson(name); err != nil {
+			t.Errorf("%s should be loaded from its mount, not %v", name, err)
+		}
+	}
+}
+
+func TestMountZip(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	dir := writeFiles(t, nil)
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "base.pak")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("sfx/jump.wav")
+	w.Write([]byte("RIFF...."))
+	zw.Close()
+	f.Close()
+
+	if err := Files.MountZip(archive); err != nil {
+		t.Fatalf("mounting the archive should succeed, not %v", err)
+	}
+
+	Files.Add("./sfx/jump.wav")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatalf("loading should succeed, not %v", err)
+	}
+
+	sound, err := Files.GetSound("jump.wav")
+	if err != nil {
+		t.Fatalf("jump.wav should be available, not %v", err)
+	}
+	defer sound.Close()
+
+	if _, err := sound.Seek(4, io.SeekStart); err != nil {
+		t.Errorf("sounds from archives should be seekable, not %v", err)
+	}
+	if rest, _ := io.ReadAll(sound); string(rest) != "...." {
+		t.Errorf("sound should be read from the archive, not %q", rest)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	"io/ioutil"
-	"log"
 
 	"fmt"
 	"github.com/golang/freetype"
@@ -27,10 +25,10 @@ type Font struct {
 	ttf  *truetype.Font
 }
 
-// Create is for loading fonts from the disk, given a location
+// Create is for loading fonts from the file system of Files, given a location
 func (f *Font) Create() error {
 	// Read and parse the font
-	ttfBytes, err := ioutil.ReadFile(f.URL)
+	ttfBytes, err := Files.ReadFile(f.URL)
 	if err != nil {
 		return err
 	}
@@ -47,10 +45,13 @@ func (f *Font) Create() error {
 // CreatePreloaded is for loading fonts which have already been defined (and loaded) within Preload
 func (f *Font) CreatePreloaded() error {
 	var ok bool
-	f.ttf, ok = Files.fonts[f.URL]
+	f.ttf, ok = Files.asset(f.URL).(*truetype.Font)
 	if !ok {
 		return fmt.Errorf("could not find preloaded font: %s", f.URL)
 	}
+	if f.Size == 0 {
+		f.Size = Files.Settings(f.URL).Size
+	}
 
 	return nil
 }
@@ -71,7 +72,7 @@ func (f *Font) TextDimensions(text string) (int, int, int) {
 		g := truetype.GlyphBuf{}
 		err := g.Load(fnt, fupe, idx, font.HintingNone)
 		if err != nil {
-			log.Println(err)
+			logError(LogAssets, "could not load glyph", "char", string(char), "err", err)
 			return 0, 0, 0
 		}
 		totalWidth += hm.AdvanceWidth
@@ -122,7 +123,7 @@ func (f *Font) Render(text string) *Texture {
 	pt := fixed.P(0, int(yBearing))
 	_, err := c.DrawString(text, pt)
 	if err != nil {
-		log.Println(err)
+		logError(LogAssets, "could not draw text", "text", text, "err", err)
 		return nil
 	}
 
@@ -0,0 +1,318 @@
+package engi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// FrameSheet holds the named frames of a spritesheet which was exported by TexturePacker or Aseprite, as a JSON
+// file in either the hash or the array format. It is loaded using the "spritesheet" kind, along with its image:
+//
+//	engi.Files.AddAs("spritesheet", "hero.json")
+//
+// Trimmed and rotated frames are drawn as they were before being packed.
+type FrameSheet struct {
+	Texture *Texture
+
+	frames []*Frame
+	byName map[string]int
+	// actions are the animation tags of Aseprite
+	actions []*AnimationAction
+	// first is the Texture of the first frame, which is the image of an animated GIF
+	first *Texture
+}
+
+// Frame is a single named frame of a FrameSheet
+type Frame struct {
+	Name   string
+	Region *Region
+	// Duration is the number of seconds the frame is shown when animating, or 0 if the sheet doesn't say
+	Duration float32
+	// Pivot is the point around which the frame should be positioned and rotated, relative to its size;
+	// 0.5, 0.5 is the center
+	Pivot Point
+}
+
+// Frames returns all frames, in the order in which they were exported
+func (s *FrameSheet) Frames() []*Frame {
+	return s.frames
+}
+
+// Frame returns the frame with the given name, or nil
+func (s *FrameSheet) Frame(name string) *Frame {
+	if i, ok := s.byName[name]; ok {
+		return s.frames[i]
+	}
+	return nil
+}
+
+// Region returns the Region of the frame with the given name, or nil
+func (s *FrameSheet) Region(name string) *Region {
+	if f := s.Frame(name); f != nil {
+		return f.Region
+	}
+	return nil
+}
+
+// still returns a Texture which draws the first frame from the texture of the sheet; it's kept up to date
+// when the sheet is reloaded
+func (s *FrameSheet) still() *Texture {
+	if s.first == nil {
+		s.first = &Texture{}
+	}
+	if len(s.frames) == 0 {
+		return s.Texture
+	}
+
+	r := s.frames[0].Region
+	s.first.id, s.first.owner = s.Texture.id, s.Texture
+	s.first.width, s.first.height = r.width, r.height
+	s.first.u, s.first.v, s.first.u2, s.first.v2 = r.View()
+	return s.first
+}
+
+// Drawables returns the Regions of all frames in order, which are the frames the AnimationActions of the
+// sheet refer to:
+//
+//	animation := engi.NewAnimationComponent(sheet.Drawables(), 0.1)
+//	animation.AddAnimationActions(sheet.Actions())
+func (s *FrameSheet) Drawables() []Drawable {
+	drawables := make([]Drawable, len(s.frames))
+	for i, f := range s.frames {
+		drawables[i] = f.Region
+	}
+	return drawables
+}
+
+// Actions returns an AnimationAction for every animation tag, with the durations of its frames
+func (s *FrameSheet) Actions() []*AnimationAction {
+	return s.actions
+}
+
+// Action returns the AnimationAction of the animation tag with the given name, or nil
+func (s *FrameSheet) Action(name string) *AnimationAction {
+	for _, action := range s.actions {
+		if action.Name == name {
+			return action
+		}
+	}
+	return nil
+}
+
+// NewAction creates an AnimationAction from the named frames, with their durations, i.e. for TexturePacker
+// sheets which don't have animation tags
+func (s *FrameSheet) NewAction(name string, frames ...string) (*AnimationAction, error) {
+	action := &AnimationAction{Name: name}
+	for _, frame := range frames {
+		i, ok := s.byName[frame]
+		if !ok {
+			return nil, fmt.Errorf("no frame named %s", frame)
+		}
+		action.Frames = append(action.Frames, i)
+		action.Durations = append(action.Durations, s.frames[i].Duration)
+	}
+	return action, nil
+}
+
+// FrameSheet returns the FrameSheet with the given name, or nil
+func (l *Loader) FrameSheet(name string) *FrameSheet {
+	sheet, _ := getAsset[*FrameSheet](l, "spritesheet", name)
+	return sheet
+}
+
+type sheetRect struct {
+	X, Y, W, H float32
+}
+
+type sheetFrame struct {
+	Filename         string    `json:"filename"`
+	Frame            sheetRect `json:"frame"`
+	Rotated          bool      `json:"rotated"`
+	Trimmed          bool      `json:"trimmed"`
+	SpriteSourceSize sheetRect `json:"spriteSourceSize"`
+	SourceSize       sheetRect `json:"sourceSize"`
+	Pivot            *Point    `json:"pivot"`
+	// Duration is in milliseconds
+	Duration float32 `json:"duration"`
+}
+
+type sheetFile struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		Image     string     `json:"image"`
+		FrameTags []sheetTag `json:"frameTags"`
+	} `json:"meta"`
+}
+
+// sheetTag is an animation tag of Aseprite, from and to are indexes of frames
+type sheetTag struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+}
+
+// decodedSheet is a FrameSheet that has been decoded in the background, and still has to be uploaded
+type decodedSheet struct {
+	file   sheetFile
+	frames []sheetFrame
+	image  Image
+	// source reads the image again, for the texture of the sheet
+	source func() (Image, error)
+}
+
+func decodeFrameSheet(r Resource) (interface{}, error) {
+	data, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var d decodedSheet
+	if err := json.Unmarshal(data, &d.file); err != nil {
+		return nil, fmt.Errorf("could not parse spritesheet: %v", err)
+	}
+	if d.frames, err = decodeSheetFrames(d.file.Frames); err != nil {
+		return nil, fmt.Errorf("could not parse spritesheet frames: %v", err)
+	}
+	if d.file.Meta.Image == "" {
+		return nil, fmt.Errorf("spritesheet has no image")
+	}
+
+	img := NewResource(resolvePath(r.url, d.file.Meta.Image))
+	img.fsys = r.fsys
+	if d.image, err = loadImage(img); err != nil {
+		return nil, err
+	}
+	d.source = func() (Image, error) {
+		return loadImage(img)
+	}
+	return &d, nil
+}
+
+// decodeSheetFrames decodes the frames in the order of the file, which matters for animation tags, from either
+// an array, or an object of frames by name
+func decodeSheetFrames(data json.RawMessage) ([]sheetFrame, error) {
+	var frames []sheetFrame
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err := json.Unmarshal(data, &frames)
+		return frames, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var f sheetFrame
+		if err := dec.Decode(&f); err != nil {
+			return nil, err
+		}
+		f.Filename = name.(string)
+		frames = append(frames, f)
+	}
+	return frames, nil
+}
+
+func finishFrameSheet(r Resource, data interface{}) (interface{}, error) {
+	d := data.(*decodedSheet)
+	tex := NewTexture(d.image)
+	tex.source = d.source
+	return d.sheet(tex)
+}
+
+// reloadFrameSheet updates the FrameSheet in place, uploading the image into its existing texture
+func reloadFrameSheet(asset interface{}, r Resource, data interface{}) error {
+	sheet, d := asset.(*FrameSheet), data.(*decodedSheet)
+	sheet.Texture.upload(d.image)
+	sheet.Texture.source = d.source
+
+	reloaded, err := d.sheet(sheet.Texture)
+	if err != nil {
+		return err
+	}
+	reloaded.first = sheet.first
+	*sheet = *reloaded
+	return nil
+}
+
+func unloadFrameSheet(asset interface{}) {
+	asset.(*FrameSheet).Texture.Delete()
+}
+
+func frameSheetSize(asset interface{}) int {
+	return imageSize(asset.(*FrameSheet).Texture)
+}
+
+// sheet creates the FrameSheet, using the texture of its image
+func (d *decodedSheet) sheet(tex *Texture) (*FrameSheet, error) {
+	sheet := &FrameSheet{Texture: tex, byName: make(map[string]int)}
+
+	for i, f := range d.frames {
+		frame := &Frame{Name: f.Filename, Duration: f.Duration / 1000, Pivot: Point{0.5, 0.5}}
+		if f.Pivot != nil {
+			frame.Pivot = *f.Pivot
+		}
+
+		// Rotated frames are stored rotated clockwise, so their width and height are swapped in the texture
+		w, h := f.Frame.W, f.Frame.H
+		if f.Rotated {
+			frame.Region = NewRegion(tex, f.Frame.X, f.Frame.Y, h, w)
+			frame.Region.width, frame.Region.height = w, h
+			frame.Region.rotated = true
+		} else {
+			frame.Region = NewRegion(tex, f.Frame.X, f.Frame.Y, w, h)
+		}
+
+		if f.Trimmed {
+			frame.Region.trimmed = true
+			frame.Region.trimX, frame.Region.trimY = f.SpriteSourceSize.X, f.SpriteSourceSize.Y
+			frame.Region.trimW, frame.Region.trimH = w, h
+			frame.Region.width, frame.Region.height = f.SourceSize.W, f.SourceSize.H
+		}
+
+		sheet.frames = append(sheet.frames, frame)
+		sheet.byName[f.Filename] = i
+	}
+
+	for _, tag := range d.file.Meta.FrameTags {
+		if tag.From < 0 || tag.To >= len(sheet.frames) || tag.From > tag.To {
+			return nil, fmt.Errorf("animation tag %s refers to frames %d to %d, but there are %d", tag.Name, tag.From, tag.To, len(sheet.frames))
+		}
+
+		var frames []int
+		for i := tag.From; i <= tag.To; i++ {
+			frames = append(frames, i)
+		}
+
+		switch tag.Direction {
+		case "", "forward":
+		case "reverse":
+			sort.Sort(sort.Reverse(sort.IntSlice(frames)))
+		case "pingpong", "pingpong_reverse":
+			// Back and forth, without showing the frames at either end twice in a row
+			for i := len(frames) - 2; i > 0; i-- {
+				frames = append(frames, frames[i])
+			}
+			if tag.Direction == "pingpong_reverse" {
+				n := tag.To - tag.From
+				frames = append(frames[n:], frames[:n]...)
+			}
+		default:
+			return nil, fmt.Errorf("animation tag %s has unknown direction %s", tag.Name, tag.Direction)
+		}
+
+		action := &AnimationAction{Name: tag.Name, Frames: frames}
+		for _, i := range frames {
+			action.Durations = append(action.Durations, sheet.frames[i].Duration)
+		}
+		sheet.actions = append(sheet.actions, action)
+	}
+
+	return sheet, nil
+}
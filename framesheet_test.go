@@ -0,0 +1,153 @@
+package engi
+
+import (
+	"image/color"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFrameSheet(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	Files.Mount(fstest.MapFS{
+		"sprites/sheet.png": {Data: colorPNG(t, 16, 16, color.NRGBA{255, 0, 0, 255})},
+		"sprites/packed.json": {Data: []byte(`{
+			"frames": {
+				"walk-1": {"frame": {"x": 0, "y": 0, "w": 4, "h": 8}, "trimmed": true,
+					"spriteSourceSize": {"x": 2, "y": 1, "w": 4, "h": 8}, "sourceSize": {"w": 8, "h": 10},
+					"pivot": {"x": 0.5, "y": 1}},
+				"walk-0": {"frame": {"x": 4, "y": 0, "w": 6, "h": 4}, "rotated": true,
+					"sourceSize": {"w": 6, "h": 4}}
+			},
+			"meta": {"image": "sheet.png", "size": {"w": 16, "h": 16}}
+		}`)},
+		"sprites/hero.json": {Data: []byte(`{
+			"frames": [
+				{"filename": "hero 0", "frame": {"x": 0, "y": 0, "w": 8, "h": 8}, "duration": 100},
+				{"filename": "hero 1", "frame": {"x": 8, "y": 0, "w": 8, "h": 8}, "duration": 200},
+				{"filename": "hero 2", "frame": {"x": 0, "y": 8, "w": 8, "h": 8}, "duration": 300}
+			],
+			"meta": {"image": "sheet.png", "frameTags": [
+				{"name": "idle", "from": 0, "to": 0, "direction": "forward"},
+				{"name": "back", "from": 0, "to": 2, "direction": "reverse"},
+				{"name": "bounce", "from": 0, "to": 2, "direction": "pingpong"}
+			]}
+		}`)},
+		"sprites/broken.json": {Data: []byte(`{"frames": [], "meta": {"image": "sheet.png", "frameTags": [
+			{"name": "idle", "from": 0, "to": 3}
+		]}}`)},
+	})
+	Files.AddAs("spritesheet", "sprites/packed.json", "sprites/hero.json")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	packed := Files.FrameSheet("packed.json")
+	if packed == nil {
+		t.Fatal("packed.json should be loaded as a FrameSheet")
+	}
+	if frames := packed.Frames(); len(frames) != 2 || frames[0].Name != "walk-1" || frames[1].Name != "walk-0" {
+		t.Errorf("the frames should be in the order of the file, not %v", frames)
+	}
+
+	trimmed := packed.Frame("walk-1")
+	if trimmed.Region.Width() != 8 || trimmed.Region.Height() != 10 {
+		t.Errorf("a trimmed frame should have its original size, not %vx%v", trimmed.Region.Width(), trimmed.Region.Height())
+	}
+	if trimmed.Pivot != (Point{0.5, 1}) {
+		t.Errorf("the pivot should be read, not %v", trimmed.Pivot)
+	}
+	ren := &RenderComponent{drawable: trimmed.Region, scale: Point{1, 1}, Transparency: 1, Color: color.White}
+	if buf := ren.generateBufferContent(); buf[0] != 2 || buf[1] != 1 || buf[10] != 6 || buf[11] != 9 {
+		t.Errorf("a trimmed frame should be drawn where it was cut out, not from %v,%v to %v,%v", buf[0], buf[1], buf[10], buf[11])
+	}
+
+	rotated := packed.Region("walk-0")
+	if rotated.Width() != 6 || rotated.Height() != 4 {
+		t.Errorf("a rotated frame should have its unrotated size, not %vx%v", rotated.Width(), rotated.Height())
+	}
+	if u, v, u2, v2 := rotated.View(); u != 4.0/16 || v != 0 || u2 != 8.0/16 || v2 != 6.0/16 {
+		t.Errorf("a rotated frame should be viewed at its rotated rectangle, not %v,%v %v,%v", u, v, u2, v2)
+	}
+	ren.drawable = rotated
+	if buf := ren.generateBufferContent(); buf[2] != 8.0/16 || buf[3] != 0 {
+		t.Errorf("the top left corner of a rotated frame should be at the top right of the view, not %v,%v", buf[2], buf[3])
+	}
+	if pivot := packed.Frame("walk-0").Pivot; pivot != (Point{0.5, 0.5}) {
+		t.Errorf("the pivot should be the center by default, not %v", pivot)
+	}
+
+	action, err := packed.NewAction("walk", "walk-0", "walk-1")
+	if err != nil || len(action.Frames) != 2 || action.Frames[0] != 1 || action.Frames[1] != 0 {
+		t.Errorf("the action should refer to the frames by index, not %v (%v)", action, err)
+	}
+	if _, err := packed.NewAction("run", "run-0"); err == nil {
+		t.Error("creating an action from a missing frame should fail")
+	}
+
+	hero := Files.FrameSheet("hero.json")
+	if hero.Texture == nil || hero.Texture.Width() != 16 {
+		t.Error("the image should be loaded along with the sheet")
+	}
+	for name, frames := range map[string][]int{"idle": {0}, "back": {2, 1, 0}, "bounce": {0, 1, 2, 1}} {
+		action := hero.Action(name)
+		if action == nil {
+			t.Errorf("tag %s should be an action", name)
+			continue
+		}
+		if len(action.Frames) != len(frames) || len(action.Durations) != len(frames) {
+			t.Errorf("tag %s should have frames %v, not %v", name, frames, action.Frames)
+			continue
+		}
+		for i := range frames {
+			if action.Frames[i] != frames[i] || action.Durations[i] != float32(frames[i]+1)/10 {
+				t.Errorf("tag %s should have frames %v, not %v with durations %v", name, frames, action.Frames, action.Durations)
+				break
+			}
+		}
+	}
+
+	ac := NewAnimationComponent(hero.Drawables(), 1)
+	ac.AddAnimationActions(hero.Actions())
+	ac.SelectAnimationByName("bounce")
+	for i, want := range []float32{0.1, 0.2, 0.3, 0.2, 0.1} {
+		if got := ac.frameDuration(); got != want {
+			t.Errorf("frame %d should be shown for %v seconds, not %v", i, want, got)
+		}
+		ac.NextFrame()
+	}
+	ac.SelectAnimationByName("idle")
+	if ac.index != 0 {
+		t.Error("selecting another animation should start from its first frame")
+	}
+
+	Files.AddAs("spritesheet", "sprites/broken.json")
+	if err := Files.Load(func() {}); err == nil {
+		t.Error("a tag referring to missing frames should fail to load")
+	}
+
+	if err := Files.Unload("sprites/hero.json"); err != nil {
+		t.Errorf("unloading the sheet should succeed, not %v", err)
+	}
+}
+
+func TestFrameSheetManifest(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	Files.Mount(fstest.MapFS{
+		"bundle.json": {Data: []byte(`{"spritesheets": [{"url": "art/hero.json"}]}`)},
+		"art/hero.json": {Data: []byte(`{
+			"frames": {"hero": {"frame": {"x": 0, "y": 0, "w": 2, "h": 2}}},
+			"meta": {"image": "hero.png"}
+		}`)},
+		"art/hero.png": {Data: []byte(encodePNG(t, 2, 2))},
+	})
+	if err := Files.LoadManifest("bundle.json"); err != nil {
+		t.Fatalf("loading the manifest should succeed, not %v", err)
+	}
+	if sheet := Files.FrameSheet("art/hero.json"); sheet == nil || sheet.Region("hero") == nil {
+		t.Error("the spritesheet should be loaded from the manifest")
+	}
+}
@@ -0,0 +1,102 @@
+package engi
+
+// FrameStage is a moment during each frame at which hooks can run
+type FrameStage uint8
+
+const (
+	// BeforeInput runs before the input of the frame is polled
+	BeforeInput FrameStage = iota
+	// BeforeUpdate runs after polling input, before the Scenes and their Systems are updated
+	BeforeUpdate
+	// AfterUpdate runs after the Scenes and their Systems have been updated and rendered
+	AfterUpdate
+	// BeforePresent runs right before the rendered frame is shown on screen
+	BeforePresent
+	// AfterPresent runs after the rendered frame has been shown on screen, at the very end of the frame
+	AfterPresent
+
+	frameStages
+)
+
+// Hook is a handle to a function that runs at a given FrameStage of every frame
+type Hook struct {
+	stage   FrameStage
+	order   int
+	fn      func()
+	removed bool
+}
+
+// Remove makes sure the Hook doesn't run anymore, starting right away
+func (h *Hook) Remove() {
+	if h.removed {
+		return
+	}
+	h.removed = true
+
+	// Hooks are copied on write, so they can be added and removed while running
+	old := hooks[h.stage]
+	updated := make([]*Hook, 0, len(old))
+	for _, other := range old {
+		if other != h {
+			updated = append(updated, other)
+		}
+	}
+	hooks[h.stage] = updated
+}
+
+var hooks [frameStages][]*Hook
+
+// AddHook runs fn at the given stage of every frame, in every mode, including headless mode. Hooks with a
+// lower order run first; hooks with the same order run in the order in which they were added. Hooks added
+// while a stage is running, run from the next frame on.
+func AddHook(stage FrameStage, order int, fn func()) *Hook {
+	h := &Hook{stage: stage, order: order, fn: fn}
+
+	old := hooks[stage]
+	updated := make([]*Hook, 0, len(old)+1)
+	i := 0
+	for ; i < len(old) && old[i].order <= order; i++ {
+		updated = append(updated, old[i])
+	}
+	updated = append(updated, h)
+	updated = append(updated, old[i:]...)
+	hooks[stage] = updated
+
+	return h
+}
+
+func runHooks(stage FrameStage) {
+	for _, h := range hooks[stage] {
+		if !h.removed {
+			h.fn()
+		}
+	}
+}
+
+// runFrame runs a single frame; present shows the rendered frame on screen, and is nil in headless mode
+func runFrame(present func()) {
+	runHooks(BeforeInput)
+
+	// First check for new keypresses
+	pollInput()
+
+	runHooks(BeforeUpdate)
+
+	// Then update the Scenes and all their Systems
+	updateScenes()
+
+	runHooks(AfterUpdate)
+
+	// Lastly, forget keypresses and show the frame
+	if !headless {
+		Mouse.ScrollX, Mouse.ScrollY = 0, 0
+	}
+
+	runHooks(BeforePresent)
+	if present != nil {
+		present()
+	}
+	runHooks(AfterPresent)
+
+	Time.Tick()
+}
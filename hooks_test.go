@@ -0,0 +1,40 @@
+package engi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	resetScenes()
+	hooks = [frameStages][]*Hook{}
+	defer func() { hooks = [frameStages][]*Hook{} }()
+
+	SetScene(&stackScene{name: "hooks"}, false)
+
+	var calls []string
+	record := func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+
+	AddHook(AfterPresent, 0, record("present"))
+	AddHook(BeforeUpdate, 1, record("update-late"))
+	AddHook(BeforeUpdate, 0, record("update-early"))
+	AddHook(BeforeInput, 0, record("input"))
+
+	var once *Hook
+	once = AddHook(AfterUpdate, 0, func() {
+		calls = append(calls, "once")
+		once.Remove()
+	})
+	removed := AddHook(AfterUpdate, 1, record("removed"))
+	removed.Remove()
+
+	runFrame(nil)
+	runFrame(nil)
+
+	expected := "input update-early update-late once present input update-early update-late present"
+	if got := strings.Join(calls, " "); got != expected {
+		t.Errorf("hooks should run as %q, not %q", expected, got)
+	}
+}
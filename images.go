@@ -0,0 +1,118 @@
+package engi
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strconv"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// imageKinds are the kinds of resources which are images; they are decoded by the loader of the format of
+// their contents rather than that of their extension, and so are files with an extension that isn't known
+// at all, see sniffKind
+var imageKinds = map[string]bool{"png": true, "jpg": true, "jpeg": true, "gif": true, "bmp": true, "webp": true}
+
+// decodeGif decodes all frames of the GIF, which are loaded as a FrameSheet with a single AnimationAction,
+// named after the GIF, that shows them for their own delay. The frames are named by their index.
+func decodeGif(r Resource) (interface{}, error) {
+	file, err := r.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	g, err := gif.DecodeAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("gif has no frames")
+	}
+
+	w, h := g.Config.Width, g.Config.Height
+	if w == 0 || h == 0 {
+		b := g.Image[0].Bounds()
+		w, h = b.Max.X, b.Max.Y
+	}
+
+	// The frames are laid out in a grid, so they don't exceed the maximum size of a texture as soon as a
+	// single row would
+	columns := int(math.Ceil(math.Sqrt(float64(len(g.Image)))))
+	rows := (len(g.Image) + columns - 1) / columns
+	pixels := image.NewNRGBA(image.Rect(0, 0, columns*w, rows*h))
+
+	// Frames only contain what changed since the previous frame, so they are drawn onto a canvas in turn
+	canvas := image.NewNRGBA(image.Rect(0, 0, w, h))
+	d := &decodedSheet{}
+	for i, frame := range g.Image {
+		var previous *image.NRGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewNRGBA(canvas.Rect)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		x, y := i%columns*w, i/columns*h
+		draw.Draw(pixels, image.Rect(x, y, x+w, y+h), canvas, image.Point{}, draw.Src)
+
+		delay := 0
+		if i < len(g.Delay) {
+			delay = g.Delay[i]
+		}
+		d.frames = append(d.frames, sheetFrame{
+			Filename: strconv.Itoa(i),
+			Frame:    sheetRect{float32(x), float32(y), float32(w), float32(h)},
+			// The delay is in hundredths of a second, and the duration in milliseconds
+			Duration: float32(delay * 10),
+		})
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	d.file.Meta.FrameTags = []sheetTag{{Name: r.name, To: len(d.frames) - 1}}
+	d.image = nrgbaImage{pixels}
+	d.source = func() (Image, error) {
+		d, err := decodeGif(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.(*decodedSheet).image, nil
+	}
+	return d, nil
+}
+
+// sniffKind determines the kind of the resource by its contents, which only works for images; it's needed for
+// files that are served with the wrong suffix, or without one at all. It's called in the background.
+func sniffKind(r Resource) (string, bool) {
+	file, err := r.Open()
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	_, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return "", false
+	}
+	if format == "jpeg" {
+		return "jpg", true
+	}
+	return format, true
+}
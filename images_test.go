@@ -0,0 +1,121 @@
+package engi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/image/bmp"
+)
+
+func TestAnimatedGif(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	palette := color.Palette{color.Transparent, color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}}
+	frame := func(r image.Rectangle, index uint8) *image.Paletted {
+		img := image.NewPaletted(r, palette)
+		for i := range img.Pix {
+			img.Pix[i] = index
+		}
+		return img
+	}
+
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 4, 4), 1),
+			frame(image.Rect(2, 2, 4, 4), 2),
+			frame(image.Rect(0, 0, 1, 1), 2),
+		},
+		Delay:    []int{10, 25, 0},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalNone},
+		Config:   image.Config{ColorModel: palette, Width: 4, Height: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Files.Mount(fstest.MapFS{"spinner.gif": {Data: buf.Bytes()}, "cdn/loader.png": {Data: buf.Bytes()}})
+	Files.Add("spinner.gif", "cdn/loader.png")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := Files.FrameSheet("spinner.gif")
+	if sheet == nil || len(sheet.Frames()) != 3 {
+		t.Fatalf("every frame of the gif should be loaded, not %v", sheet)
+	}
+	if img := Files.Image("spinner.gif"); img == nil || img.Width() != 4 || img.Height() != 4 {
+		t.Errorf("the image of a gif should be its first frame of 4x4, not %v", img)
+	} else if u, v, _, _ := img.View(); u != 0 || v != 0 || img.Width() >= sheet.Texture.Width() {
+		t.Errorf("the image of a gif should draw its first frame from the texture of the sheet, not at %v, %v", u, v)
+	}
+	if served := Files.FrameSheet("loader.png"); served == nil || len(served.Frames()) != 3 {
+		t.Error("every frame of a gif served with the extension of another image should be loaded")
+	}
+
+	action := sheet.Action("spinner.gif")
+	if action == nil || len(action.Durations) != 3 || action.Durations[0] != 0.1 || action.Durations[1] != 0.25 || action.Durations[2] != 0 {
+		t.Errorf("the gif should be an animation with the delays of its frames, not %v", action)
+	}
+
+	pixels := sheet.Texture.image.Data().(*image.NRGBA)
+	at := func(frame string, x, y int) color.NRGBA {
+		u, v, _, _ := sheet.Region(frame).View()
+		return pixels.NRGBAAt(int(u*sheet.Texture.Width())+x, int(v*sheet.Texture.Height())+y)
+	}
+	if c := at("1", 0, 0); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("frames should be drawn over the previous frame, but found %v", c)
+	}
+	if c := at("1", 3, 3); c != (color.NRGBA{0, 0, 255, 255}) {
+		t.Errorf("frames should be drawn at their own bounds, but found %v", c)
+	}
+	if c := at("2", 3, 3); c.A != 0 {
+		t.Errorf("a frame disposed to the background should be cleared, but found %v", c)
+	}
+}
+
+func TestImageFormats(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	var bmpData, jpegData bytes.Buffer
+	if err := bmp.Encode(&bmpData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	Files.Mount(fstest.MapFS{
+		"tiles.bmp":     {Data: bmpData.Bytes()},
+		"photo.jpg":     {Data: jpegData.Bytes()},
+		"cdn/wrong.png": {Data: jpegData.Bytes()},
+		"cdn/a1b2c3":    {Data: bmpData.Bytes()},
+		"cdn/hero.bin":  {Data: []byte(encodePNG(t, 5, 5))},
+		"cdn/still.gif": {Data: []byte(encodePNG(t, 4, 4))},
+		"cdn/notes.txt": {Data: []byte("not an image")},
+	})
+	Files.Add("tiles.bmp", "photo.jpg", "cdn/wrong.png", "cdn/a1b2c3", "cdn/hero.bin", "cdn/still.gif", "cdn/notes.txt")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatalf("loading should succeed, not %v", err)
+	}
+
+	for name, width := range map[string]float32{"tiles.bmp": 3, "photo.jpg": 3, "wrong.png": 3, "a1b2c3": 3, "hero.bin": 5, "still.gif": 4} {
+		tex, err := Files.GetImage(name)
+		if err != nil {
+			t.Errorf("%s should be loaded, not %v", name, err)
+		} else if tex.Width() != float32(width) {
+			t.Errorf("%s should be %v pixels wide, not %v", name, width, tex.Width())
+		}
+	}
+	if Files.asset("notes.txt") != nil {
+		t.Error("a file of an unknown kind should not be loaded")
+	}
+}
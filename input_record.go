@@ -0,0 +1,249 @@
+package engi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	recorder *InputRecorder
+	replay   *InputReplay
+
+	recordingHeader = []byte("ENGIREC1")
+)
+
+// Flags which indicate what has changed in a recorded frame
+const (
+	frameMousePosition uint8 = 1 << iota
+	frameMouseScroll
+	frameMouseButton
+	frameKeys
+)
+
+// InputRecorder writes the input of every frame, and the time difference between them, to an io.Writer.
+// Only values that changed since the previous frame are written, to keep recordings compact.
+type InputRecorder struct {
+	w     *bufio.Writer
+	err   error
+	keys  map[Key]bool
+	mouse mouse
+}
+
+// NewInputRecorder creates an InputRecorder which writes to w; use RecordInput to start recording
+func NewInputRecorder(w io.Writer) (*InputRecorder, error) {
+	r := &InputRecorder{w: bufio.NewWriter(w), keys: make(map[Key]bool)}
+	if _, err := r.w.Write(recordingHeader); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// record writes the current input state and time difference as a single frame
+func (r *InputRecorder) record() {
+	if r.err != nil {
+		return
+	}
+
+	var changed []Key
+	for k, down := range keyStates {
+		if r.keys[k] != down {
+			changed = append(changed, k)
+			r.keys[k] = down
+		}
+	}
+
+	var flags uint8
+	if Mouse.X != r.mouse.X || Mouse.Y != r.mouse.Y {
+		flags |= frameMousePosition
+	}
+	if Mouse.ScrollX != 0 || Mouse.ScrollY != 0 {
+		flags |= frameMouseScroll
+	}
+	if Mouse.Action != r.mouse.Action || Mouse.Button != r.mouse.Button || Mouse.Modifer != r.mouse.Modifer {
+		flags |= frameMouseButton
+	}
+	if len(changed) > 0 {
+		flags |= frameKeys
+	}
+	r.mouse = Mouse
+
+	r.write(flags, Time.UnscaledDelta())
+	if flags&frameMousePosition != 0 {
+		r.write(Mouse.X, Mouse.Y)
+	}
+	if flags&frameMouseScroll != 0 {
+		r.write(Mouse.ScrollX, Mouse.ScrollY)
+	}
+	if flags&frameMouseButton != 0 {
+		r.write(int8(Mouse.Action), uint8(Mouse.Button), uint8(Mouse.Modifer))
+	}
+	if flags&frameKeys != 0 {
+		r.write(uint16(len(changed)))
+		for _, k := range changed {
+			r.write(uint16(k), r.keys[k])
+		}
+	}
+}
+
+func (r *InputRecorder) write(values ...interface{}) {
+	for _, v := range values {
+		if r.err != nil {
+			return
+		}
+		r.err = binary.Write(r.w, binary.LittleEndian, v)
+	}
+}
+
+// Close flushes the recorded frames to the underlying io.Writer, and returns the first error encountered
+// while recording; it does not close the io.Writer itself
+func (r *InputRecorder) Close() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.w.Flush()
+}
+
+// InputReplay reads frames written by an InputRecorder, and feeds them to the engine instead of the actual input
+type InputReplay struct {
+	r    *bufio.Reader
+	err  error
+	done bool
+	// keys and mouse are the replayed input, which replaces the actual input every frame
+	keys  map[Key]bool
+	mouse mouse
+}
+
+// NewInputReplay creates an InputReplay which reads from r; use ReplayInput to start replaying
+func NewInputReplay(r io.Reader) (*InputReplay, error) {
+	rep := &InputReplay{r: bufio.NewReader(r), keys: make(map[Key]bool)}
+
+	header := make([]byte, len(recordingHeader))
+	if _, err := io.ReadFull(rep.r, header); err != nil {
+		return nil, err
+	}
+	if string(header) != string(recordingHeader) {
+		return nil, errors.New("not an input recording")
+	}
+
+	return rep, nil
+}
+
+// apply reads the next frame, and applies it to keyStates, Mouse and Time; it returns false when there are no
+// more frames
+func (rep *InputReplay) apply() bool {
+	if rep.done {
+		return false
+	}
+
+	var (
+		flags uint8
+		delta float32
+	)
+	rep.read(&flags, &delta)
+	if rep.err != nil {
+		rep.done = true
+		return false
+	}
+
+	if flags&frameMousePosition != 0 {
+		rep.read(&rep.mouse.X, &rep.mouse.Y)
+	}
+
+	rep.mouse.ScrollX, rep.mouse.ScrollY = 0, 0
+	if flags&frameMouseScroll != 0 {
+		rep.read(&rep.mouse.ScrollX, &rep.mouse.ScrollY)
+	}
+
+	if flags&frameMouseButton != 0 {
+		var (
+			action           int8
+			button, modifier uint8
+		)
+		rep.read(&action, &button, &modifier)
+		rep.mouse.Action, rep.mouse.Button, rep.mouse.Modifer = Action(action), MouseButton(button), Modifier(modifier)
+	}
+
+	if flags&frameKeys != 0 {
+		var count uint16
+		rep.read(&count)
+		for i := uint16(0); i < count; i++ {
+			var (
+				key  uint16
+				down bool
+			)
+			rep.read(&key, &down)
+			rep.keys[Key(key)] = down
+		}
+	}
+
+	if rep.err != nil {
+		rep.done = true
+		return false
+	}
+
+	// The actual input of the window is overwritten, so only the replayed input is used
+	Mouse = rep.mouse
+	for k := range keyStates {
+		keyStates[k] = rep.keys[k]
+	}
+	for k, down := range rep.keys {
+		keyStates[k] = down
+	}
+
+	Time.setUnscaledDelta(float64(delta))
+	return true
+}
+
+func (rep *InputReplay) read(values ...interface{}) {
+	for _, v := range values {
+		if rep.err != nil {
+			return
+		}
+		rep.err = binary.Read(rep.r, binary.LittleEndian, v)
+	}
+}
+
+// Done indicates whether or not all frames have been replayed
+func (rep *InputReplay) Done() bool {
+	return rep.done
+}
+
+// Err returns the error that ended the replay, if it wasn't the end of the recording
+func (rep *InputReplay) Err() error {
+	if rep.err == io.EOF {
+		return nil
+	}
+	return rep.err
+}
+
+// RecordInput starts recording the input of every frame to the InputRecorder; nil stops recording
+func RecordInput(r *InputRecorder) {
+	recorder = r
+}
+
+// ReplayInput feeds the frames of the InputReplay to the engine, instead of the actual input; nil stops replaying.
+// This also works in headless mode, so recorded sessions can be simulated again in tests.
+func ReplayInput(r *InputReplay) {
+	replay = r
+}
+
+// pollInput updates keyStates, Mouse and Time from either the replay or the actual input, and records it if needed
+func pollInput() {
+	// Window events, like closing or resizing the window, are handled while replaying as well
+	if !headless {
+		pollEvents()
+	}
+
+	if replay != nil && !replay.apply() {
+		replay = nil
+	}
+	if replay != nil || !headless {
+		keysUpdate()
+	}
+
+	if recorder != nil {
+		recorder.record()
+	}
+}
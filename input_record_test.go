@@ -0,0 +1,105 @@
+package engi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/paked/engi/ecs"
+)
+
+// inputSnapshot is what Systems observe during a frame
+type inputSnapshot struct {
+	space KeyState
+	mouse mouse
+	delta float32
+}
+
+type snapshotSystem struct {
+	*ecs.System
+	snapshots []inputSnapshot
+}
+
+func (s *snapshotSystem) New(*ecs.World) {
+	s.System = ecs.NewSystem()
+	s.AddEntity(ecs.NewEntity([]string{s.Type()}))
+}
+
+func (*snapshotSystem) Type() string {
+	return "snapshotSystem"
+}
+
+func (s *snapshotSystem) Update(*ecs.Entity, float32) {
+	s.snapshots = append(s.snapshots, inputSnapshot{Keys.Get(Space), Mouse, Time.UnscaledDelta()})
+}
+
+func resetInput() *snapshotSystem {
+	headless = true
+	keyStates = make(map[Key]bool)
+	Keys.mapper = make(map[Key]KeyState)
+	Mouse = mouse{}
+	Time = NewClock()
+
+	s := &snapshotSystem{}
+	currentWorld = &ecs.World{}
+	currentWorld.New()
+	currentWorld.AddSystem(s)
+	return s
+}
+
+func TestInputReplay(t *testing.T) {
+	frames := []func(){
+		func() { keyStates[Space] = true },
+		func() { Mouse.X, Mouse.Y, Mouse.Action = 10, 20, PRESS },
+		func() { Mouse.ScrollY = 1 },
+		func() { keyStates[Space] = false; Mouse.Action = RELEASE },
+	}
+
+	recorded := resetInput()
+	buf := &bytes.Buffer{}
+	rec, err := NewInputRecorder(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	RecordInput(rec)
+	for _, frame := range frames {
+		// Simulate the callbacks of an actual window
+		frame()
+		keysUpdate()
+		RunIteration()
+		Mouse.ScrollX, Mouse.ScrollY = 0, 0
+	}
+	RecordInput(nil)
+
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := resetInput()
+	rep, err := NewInputReplay(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ReplayInput(rep)
+	for range frames {
+		// The actual input of the window is overwritten by the replayed input
+		keyStates[Space] = true
+		Mouse.X, Mouse.ScrollY = 99, 5
+		RunIteration()
+	}
+
+	for i, want := range recorded.snapshots {
+		if got := replayed.snapshots[i]; got != want {
+			t.Errorf("frame %d: replayed input %+v does not match recorded input %+v", i, got, want)
+		}
+	}
+
+	RunIteration()
+	if !rep.Done() {
+		t.Error("replay should be done after all frames have been replayed")
+	}
+	if rep.Err() != nil {
+		t.Error(rep.Err())
+	}
+}
@@ -8,6 +8,9 @@ type Level struct {
 	Tiles      []*tile
 	LineBounds []Line
 	Images     []*tile
+
+	// images are the names of the tileset and image layer images, which are kept loaded along with the Level
+	images []string
 }
 
 type tile struct {
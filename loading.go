@@ -0,0 +1,334 @@
+package engi
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResourceStatus indicates how far a resource has been loaded
+type ResourceStatus uint8
+
+const (
+	// ResourcePending means the resource is still being read and decoded
+	ResourcePending ResourceStatus = iota
+	// ResourceLoaded means the resource can be used
+	ResourceLoaded
+	// ResourceFailed means the resource could not be loaded
+	ResourceFailed
+	// ResourceSkipped means the resource has been left out, because it isn't of any kind that can be loaded,
+	// or its bundle has been unloaded in the meantime
+	ResourceSkipped
+)
+
+// ResourceProgress is the progress of a single resource
+type ResourceProgress struct {
+	Name   string
+	Status ResourceStatus
+	// Err is the reason the resource could not be loaded, if its Status is ResourceFailed
+	Err error
+}
+
+// ResourceError describes a resource that could not be loaded
+type ResourceError struct {
+	Name string
+	URL  string
+	Err  error
+}
+
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("could not load %s: %v", e.URL, e.Err)
+}
+
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// LoadError lists all resources that could not be loaded
+type LoadError struct {
+	Errors []*ResourceError
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "could not load %d resource(s):", len(e.Errors))
+	for _, err := range e.Errors {
+		fmt.Fprintf(&b, "\n\t%s: %v", err.URL, err.Err)
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors of the individual resources, so they can be inspected using errors.Is and errors.As
+func (e *LoadError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+type decodeResult struct {
+	index   int
+	data    interface{}
+	err     error
+	modTime time.Time
+	// kind and loader are those of the contents of the resource, which may differ from its extension
+	kind    string
+	loader  AssetLoader
+	skipped bool
+}
+
+// Loading keeps track of resources which are being loaded in the background. Reading and decoding happens
+// on other goroutines, while anything that needs the GL context is done by Poll, on the main thread.
+type Loading struct {
+	loader    *Loader
+	resources []Resource
+	loaders   []AssetLoader
+	progress  []ResourceProgress
+	errors    []*ResourceError
+
+	decoded  chan decodeResult
+	received []bool
+	// held are decoded resources which can't be finished until the resources they depend on are
+	held     []decodeResult
+	finished int
+
+	// retained are the names of the assets which were loaded, or were loaded already, and have been given a
+	// reference on behalf of whoever started loading them
+	retained []string
+	// reload indicates the resources are reloaded in place, because their files have changed
+	reload bool
+}
+
+// LoadAsync starts loading all resources that have been added since the previous load. Assets which have
+// been loaded from the same URL already aren't loaded again, but get another reference instead.
+func (l *Loader) LoadAsync() *Loading {
+	ld := l.startLoading(l.resources, false)
+	l.resources = l.resources[:0]
+	l.loading = ld
+	return ld
+}
+
+// startLoading starts decoding the resources in the background
+func (l *Loader) startLoading(resources []Resource, reload bool) *Loading {
+	ld := &Loading{loader: l, reload: reload}
+	fsys := l.FS()
+	var pending []int
+	for _, r := range resources {
+		loader := loaders[r.kind]
+		r.fsys = fsys
+
+		ld.resources = append(ld.resources, r)
+		ld.loaders = append(ld.loaders, loader)
+		ld.progress = append(ld.progress, ResourceProgress{Name: r.name})
+
+		if a, ok := l.assets[r.name]; ok && a.url == r.url && !reload {
+			a.refs++
+			ld.retained = append(ld.retained, r.name)
+			if r.bundle != nil {
+				r.bundle.retain(a)
+			}
+			ld.progress[len(ld.progress)-1].Status = ResourceLoaded
+			ld.finished++
+			continue
+		}
+		pending = append(pending, len(ld.resources)-1)
+	}
+
+	ld.received = make([]bool, len(ld.resources))
+	ld.decoded = make(chan decodeResult, len(pending))
+	jobs := make(chan int, len(pending))
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+
+	workers := runtime.NumCPU()
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				ld.decoded <- ld.decode(i)
+			}
+		}()
+	}
+
+	return ld
+}
+
+// decode reads and decodes the resource in the background. Images are decoded by the loader of the format of
+// their contents, and so are files with an unknown extension, which are skipped if they aren't images.
+func (ld *Loading) decode(i int) decodeResult {
+	r, loader := ld.resources[i], ld.loaders[i]
+	res := decodeResult{index: i, kind: r.kind, loader: loader}
+
+	if _, known := loaders[r.kind]; !known || imageKinds[r.kind] {
+		kind, sniffed := sniffKind(r)
+		if sniffedLoader, ok := loaders[kind]; sniffed && ok && kind != r.kind {
+			res.kind, res.loader = kind, sniffedLoader
+		} else if !known {
+			res.skipped = true
+			return res
+		}
+	}
+	r.kind = res.kind
+
+	if info, err := fs.Stat(r.fsys, r.url); err == nil {
+		res.modTime = info.ModTime()
+	}
+	res.data, res.err = res.loader.Decode(r)
+	return res
+}
+
+// Loading returns the most recently started Loading, i.e. to display its progress in a loading Scene
+func (l *Loader) Loading() *Loading {
+	return l.loading
+}
+
+// Poll finishes the resources which have been decoded in the meantime, without blocking; it has to be
+// called on the main thread. It returns whether all resources are done.
+func (ld *Loading) Poll() bool {
+	for {
+		select {
+		case res := <-ld.decoded:
+			ld.receive(res)
+		default:
+			return ld.Done()
+		}
+	}
+}
+
+// Wait blocks until all resources are done; it has to be called on the main thread
+func (ld *Loading) Wait() {
+	for !ld.Done() {
+		ld.receive(<-ld.decoded)
+	}
+}
+
+func (ld *Loading) receive(res decodeResult) {
+	ld.received[res.index] = true
+	if r := &ld.resources[res.index]; res.kind != r.kind {
+		logDebug(LogAssets, "sniffed kind of resource", "name", r.name, "kind", res.kind)
+		r.kind, ld.loaders[res.index] = res.kind, res.loader
+	}
+	if res.skipped {
+		logDebug(LogAssets, "skipped resource of unknown kind", "name", ld.resources[res.index].name)
+		ld.progress[res.index].Status = ResourceSkipped
+		ld.finished++
+		ld.finishHeld()
+		return
+	}
+	if res.err != nil {
+		ld.finish(res)
+		return
+	}
+	ld.held = append(ld.held, res)
+	ld.finishHeld()
+}
+
+// order is the order in which the resource is finished: resources of the bundles it depends on come first,
+// and late resources, like levels which need their tileset images, come after the others of their bundle
+func (ld *Loading) order(i int) int {
+	order := ld.resources[i].stage * 2
+	if ld.loaders[i].Late {
+		order++
+	}
+	return order
+}
+
+// finishHeld finishes the decoded resources which don't have to wait for any resources that are still
+// being decoded, in order
+func (ld *Loading) finishHeld() {
+	limit := math.MaxInt
+	for i, received := range ld.received {
+		if !received && ld.progress[i].Status == ResourcePending && ld.order(i) < limit {
+			limit = ld.order(i)
+		}
+	}
+
+	sort.SliceStable(ld.held, func(i, j int) bool { return ld.order(ld.held[i].index) < ld.order(ld.held[j].index) })
+
+	waiting := ld.held[:0]
+	for _, res := range ld.held {
+		if ld.order(res.index) <= limit {
+			ld.finish(res)
+		} else {
+			waiting = append(waiting, res)
+		}
+	}
+	ld.held = waiting
+}
+
+func (ld *Loading) finish(res decodeResult) {
+	r := ld.resources[res.index]
+
+	// Bundles that are unloaded while they are loading don't want their resources anymore
+	if !ld.reload && r.bundle != nil && r.bundle.unloaded {
+		logDebug(LogAssets, "skipped resource of unloaded bundle", "name", r.name)
+		ld.progress[res.index].Status = ResourceSkipped
+		ld.finished++
+		return
+	}
+
+	err := res.err
+	if err == nil && ld.reload {
+		err = ld.loader.reload(r, ld.loaders[res.index], res.data)
+	} else if err == nil {
+		err = ld.loader.store(r, ld.loaders[res.index], res.data, res.modTime)
+	}
+
+	if err != nil {
+		ld.progress[res.index].Status = ResourceFailed
+		ld.progress[res.index].Err = err
+		ld.errors = append(ld.errors, &ResourceError{Name: r.name, URL: r.url, Err: err})
+		logWarn(LogAssets, "could not load resource", "url", r.url, "err", err)
+	} else {
+		ld.progress[res.index].Status = ResourceLoaded
+		if !ld.reload {
+			ld.retained = append(ld.retained, r.name)
+			if r.bundle != nil {
+				r.bundle.retain(ld.loader.assets[r.name])
+			}
+		}
+	}
+	ld.finished++
+}
+
+// Done indicates whether all resources have either been loaded, or failed to load
+func (ld *Loading) Done() bool {
+	return ld.finished == len(ld.resources)
+}
+
+// Progress returns the fraction of resources that are done, ranging from 0 to 1
+func (ld *Loading) Progress() float32 {
+	if len(ld.resources) == 0 {
+		return 1
+	}
+	return float32(ld.finished) / float32(len(ld.resources))
+}
+
+// Resources returns the progress of each resource
+func (ld *Loading) Resources() []ResourceProgress {
+	progress := make([]ResourceProgress, len(ld.progress))
+	copy(progress, ld.progress)
+	return progress
+}
+
+// Errors returns the resources which could not be loaded so far
+func (ld *Loading) Errors() []*ResourceError {
+	return ld.errors
+}
+
+// Err returns a *LoadError listing the resources which could not be loaded so far, or nil
+func (ld *Loading) Err() error {
+	if len(ld.errors) == 0 {
+		return nil
+	}
+	return &LoadError{ld.errors}
+}
@@ -0,0 +1,160 @@
+package engi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// LogLevel is the severity of a log entry; its values match those of log/slog
+type LogLevel int
+
+const (
+	LogDebug LogLevel = -4
+	LogInfo  LogLevel = 0
+	LogWarn  LogLevel = 4
+	LogError LogLevel = 8
+	// LogOff is used with SetLogLevel to silence a LogTag completely
+	LogOff LogLevel = 1 << 10
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	case LogOff:
+		return "OFF"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// LogTag is the subsystem a log entry comes from
+type LogTag string
+
+const (
+	LogEngine    LogTag = "engine"
+	LogAssets    LogTag = "assets"
+	LogAudio     LogTag = "audio"
+	LogRender    LogTag = "render"
+	LogCollision LogTag = "collision"
+	LogTMX       LogTag = "tmx"
+)
+
+// LogSink receives the log entries of the engine. Args are alternating keys and values, like with log/slog.
+type LogSink interface {
+	Log(level LogLevel, tag LogTag, msg string, args ...interface{})
+}
+
+var (
+	logMu   sync.RWMutex
+	logSink LogSink = StdLogSink(log.Default())
+
+	// defaultLogLevel is used for LogTags without a level of their own
+	defaultLogLevel = LogWarn
+	// logLevels holds the level per LogTag; the hot paths are silent by default
+	logLevels = map[LogTag]LogLevel{
+		LogRender:    LogOff,
+		LogCollision: LogOff,
+	}
+)
+
+// SetLogSink sets the LogSink which receives the log entries of the engine; nil silences the engine
+// completely. By default, warnings and errors are written to the standard logger.
+func SetLogSink(sink LogSink) {
+	logMu.Lock()
+	logSink = sink
+	logMu.Unlock()
+}
+
+// SetLogLevel sets the minimum level of the entries that are logged for the LogTag. The render and
+// collision tags are set to LogOff by default, because they are logged from within the game loop; failures
+// which aren't repeated every frame are logged under other tags.
+func SetLogLevel(tag LogTag, level LogLevel) {
+	logMu.Lock()
+	logLevels[tag] = level
+	logMu.Unlock()
+}
+
+// SetDefaultLogLevel sets the minimum level for all LogTags that don't have a level of their own; it is
+// LogWarn by default
+func SetDefaultLogLevel(level LogLevel) {
+	logMu.Lock()
+	defaultLogLevel = level
+	logMu.Unlock()
+}
+
+// logEnabled indicates whether an entry would be logged; hot paths should check it before building the entry
+func logEnabled(tag LogTag, level LogLevel) bool {
+	logMu.RLock()
+	defer logMu.RUnlock()
+
+	if logSink == nil {
+		return false
+	}
+
+	min, ok := logLevels[tag]
+	if !ok {
+		min = defaultLogLevel
+	}
+	return level >= min
+}
+
+func logEntry(level LogLevel, tag LogTag, msg string, args ...interface{}) {
+	if !logEnabled(tag, level) {
+		return
+	}
+
+	logMu.RLock()
+	sink := logSink
+	logMu.RUnlock()
+
+	sink.Log(level, tag, msg, args...)
+}
+
+func logDebug(tag LogTag, msg string, args ...interface{}) { logEntry(LogDebug, tag, msg, args...) }
+func logInfo(tag LogTag, msg string, args ...interface{})  { logEntry(LogInfo, tag, msg, args...) }
+func logWarn(tag LogTag, msg string, args ...interface{})  { logEntry(LogWarn, tag, msg, args...) }
+func logError(tag LogTag, msg string, args ...interface{}) { logEntry(LogError, tag, msg, args...) }
+
+type stdLogSink struct {
+	logger *log.Logger
+}
+
+// StdLogSink writes log entries to the *log.Logger as a single line, i.e. "WARN [audio] msg key=value"
+func StdLogSink(logger *log.Logger) LogSink {
+	return stdLogSink{logger}
+}
+
+func (s stdLogSink) Log(level LogLevel, tag LogTag, msg string, args ...interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s [%s] %s", level, tag, msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	if len(args)%2 == 1 {
+		fmt.Fprintf(&b, " %v", args[len(args)-1])
+	}
+	s.logger.Print(b.String())
+}
+
+type slogSink struct {
+	logger *slog.Logger
+}
+
+// SlogSink passes log entries on to the *slog.Logger, with the LogTag as the "tag" attribute
+func SlogSink(logger *slog.Logger) LogSink {
+	return slogSink{logger}
+}
+
+func (s slogSink) Log(level LogLevel, tag LogTag, msg string, args ...interface{}) {
+	s.logger.With("tag", string(tag)).Log(context.Background(), slog.Level(level), msg, args...)
+}
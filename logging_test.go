@@ -0,0 +1,83 @@
+package engi
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	level LogLevel
+	tag   LogTag
+	msg   string
+}
+
+type recordSink struct {
+	entries []entry
+}
+
+func (s *recordSink) Log(level LogLevel, tag LogTag, msg string, args ...interface{}) {
+	s.entries = append(s.entries, entry{level, tag, msg})
+}
+
+func TestLogLevels(t *testing.T) {
+	sink := &recordSink{}
+	SetLogSink(sink)
+	defer SetLogSink(StdLogSink(log.Default()))
+
+	logDebug(LogAssets, "debug")
+	logWarn(LogAssets, "warn")
+	logError(LogCollision, "hot path")
+
+	SetLogLevel(LogCollision, LogDebug)
+	defer SetLogLevel(LogCollision, LogOff)
+	logDebug(LogCollision, "enabled")
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("2 entries should be logged, not %v", sink.entries)
+	}
+	if sink.entries[0] != (entry{LogWarn, LogAssets, "warn"}) {
+		t.Errorf("first entry should be the warning, not %v", sink.entries[0])
+	}
+	if sink.entries[1] != (entry{LogDebug, LogCollision, "enabled"}) {
+		t.Errorf("second entry should be the enabled collision entry, not %v", sink.entries[1])
+	}
+
+	if MinimumTranslation(AABB{Max: Point{1, 1}}, AABB{Min: Point{5, 5}, Max: Point{6, 6}}) != (Point{}) {
+		t.Error("boxes that don't intersect should not be translated")
+	}
+	if len(sink.entries) != 3 || sink.entries[2].tag != LogCollision {
+		t.Errorf("collision entry should be logged once its level is enabled, entries were %v", sink.entries)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetLogSink(SlogSink(slog.New(slog.NewTextHandler(buf, nil))))
+	defer SetLogSink(StdLogSink(log.Default()))
+
+	logError(LogTMX, "could not decode", "url", "level.tmx")
+
+	out := buf.String()
+	for _, part := range []string{"level=ERROR", "tag=tmx", `msg="could not decode"`, "url=level.tmx"} {
+		if !strings.Contains(out, part) {
+			t.Errorf("output should contain %s, but was %q", part, out)
+		}
+	}
+}
+
+func TestAnimationWarnsOnce(t *testing.T) {
+	sink := &recordSink{}
+	SetLogSink(sink)
+	defer SetLogSink(StdLogSink(log.Default()))
+
+	ac := NewAnimationComponent(nil, 0.1)
+	for i := 0; i < 3; i++ {
+		ac.NextFrame()
+	}
+	if len(sink.entries) != 1 || sink.entries[0].level != LogWarn {
+		t.Errorf("a missing animation should be warned about once, not %v", sink.entries)
+	}
+}
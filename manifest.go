@@ -0,0 +1,186 @@
+package engi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// manifest is the JSON format of a bundle of assets. URLs are relative to the manifest, and the bundles it
+// depends on are loaded before it:
+//
+//	{
+//		"depends": ["common.json"],
+//		"textures": [{"url": "hero.png", "filter": "nearest"}, {"url": "icons.svg", "scale": 2}],
+//		"sounds": [{"url": "music.wav", "loop": true}],
+//		"fonts": [{"url": "hud.ttf", "size": 24}],
+//		"levels": [{"url": "world1.tmx"}],
+//		"spritesheets": [{"url": "hero.json"}],
+//		"files": [{"url": "tuning.json"}]
+//	}
+type manifest struct {
+	Depends  []string        `json:"depends"`
+	Textures []manifestAsset `json:"textures"`
+	Sounds   []manifestAsset `json:"sounds"`
+	Fonts    []manifestAsset `json:"fonts"`
+	Levels   []manifestAsset `json:"levels"`
+	// Spritesheets are the JSON files of TexturePacker and Aseprite, which are loaded as FrameSheets
+	Spritesheets []manifestAsset `json:"spritesheets"`
+	Files        []manifestAsset `json:"files"`
+}
+
+type manifestAsset struct {
+	URL string `json:"url"`
+	AssetSettings
+}
+
+// bundle is a manifest which has been added
+type bundle struct {
+	// assets are the names of the assets in the bundle, not including those of its dependencies
+	assets []string
+	stage  int
+	// retained are the names of the assets the bundle holds a reference to of its own, once they are loaded
+	retained []string
+	// unloaded is set by UnloadManifest, so resources of the bundle which are still loading aren't stored
+	unloaded bool
+}
+
+// retain gives the bundle a reference to the asset, which has been loaded for it
+func (b *bundle) retain(a *loadedAsset) {
+	a.refs++
+	b.retained = append(b.retained, a.name)
+}
+
+// AddManifest adds the assets of the bundle described by the JSON manifest, and those of the bundles it
+// depends on, so they are loaded along with the other resources that have been added. Levels are loaded
+// after their tileset images, and bundles after the bundles they depend on. Bundles which have been added
+// before, and haven't been unloaded since, aren't added again.
+//
+// Each bundle holds a reference to its assets once they are loaded, which UnloadManifest releases; the
+// references of whoever loads them, like the Scene whose Preload added the manifest, are separate.
+func (l *Loader) AddManifest(url string) error {
+	_, err := l.addManifest(url, make(map[string]*bundle), make(map[string]bool))
+	return err
+}
+
+// LoadManifest adds the manifest, and loads it right away along with the other resources that have been
+// added, like Load. Only the bundles hold a reference to their assets, so UnloadManifest unloads them.
+func (l *Loader) LoadManifest(url string) error {
+	if err := l.AddManifest(url); err != nil {
+		return err
+	}
+
+	ld := l.LoadAsync()
+	ld.Wait()
+
+	bundled := make(map[string]int)
+	for i, r := range ld.resources {
+		if r.bundle != nil && ld.progress[i].Status == ResourceLoaded {
+			bundled[r.name]++
+		}
+	}
+	retained := ld.retained[:0]
+	for _, name := range ld.retained {
+		if bundled[name] > 0 {
+			bundled[name]--
+			l.Release(name)
+		} else {
+			retained = append(retained, name)
+		}
+	}
+	ld.retained = retained
+	return ld.Err()
+}
+
+// UnloadManifest releases the references the bundle holds to its assets, so those which aren't used
+// elsewhere are unloaded, and drops those which haven't been loaded yet, or are still loading. The bundles
+// it depends on stay loaded, since they may be used by other bundles as well.
+func (l *Loader) UnloadManifest(url string) error {
+	name := assetName(url)
+	b, ok := l.bundles[name]
+	if !ok {
+		return missingAsset("manifest", url)
+	}
+	delete(l.bundles, name)
+	b.unloaded = true
+
+	queued := l.resources[:0]
+	for _, r := range l.resources {
+		if r.bundle != b {
+			queued = append(queued, r)
+		}
+	}
+	l.resources = queued
+
+	l.Release(b.retained...)
+	b.retained = nil
+	return nil
+}
+
+// addManifest adds the manifest and its dependencies; added holds the bundles which have been added already
+// by the same call to AddManifest, and visiting those which are being added, to detect cycles
+func (l *Loader) addManifest(url string, added map[string]*bundle, visiting map[string]bool) (*bundle, error) {
+	name := assetName(url)
+	if b, ok := added[name]; ok {
+		return b, nil
+	}
+	if b, ok := l.bundles[name]; ok {
+		return b, nil
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("manifest %s depends on itself", url)
+	}
+	visiting[name] = true
+
+	data, err := l.ReadFile(url)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %v", url, err)
+	}
+
+	b := &bundle{}
+	for _, dep := range m.Depends {
+		depBundle, err := l.addManifest(resolvePath(url, dep), added, visiting)
+		if err != nil {
+			return nil, err
+		}
+		if depBundle.stage >= b.stage {
+			b.stage = depBundle.stage + 1
+		}
+	}
+
+	var resources []Resource
+	// The kind of most assets follows from their extension
+	groups := []struct {
+		kind   string
+		assets []manifestAsset
+	}{{"", m.Textures}, {"", m.Sounds}, {"", m.Fonts}, {"", m.Levels}, {"spritesheet", m.Spritesheets}, {"", m.Files}}
+
+	for _, group := range groups {
+		for _, asset := range group.assets {
+			if asset.Filter != "" && asset.Filter != "linear" && asset.Filter != "nearest" {
+				return nil, fmt.Errorf("unknown filter in manifest %s: %s", url, asset.Filter)
+			}
+
+			r := NewResource(resolvePath(url, asset.URL))
+			if group.kind != "" {
+				r.kind = group.kind
+			}
+			r.settings = asset.AssetSettings
+			r.stage = b.stage
+			r.bundle = b
+			resources = append(resources, r)
+			b.assets = append(b.assets, r.name)
+		}
+	}
+
+	l.resources = append(l.resources, resources...)
+	l.bundles[name] = b
+	added[name] = b
+	delete(visiting, name)
+
+	logDebug(LogAssets, "added manifest", "url", url, "assets", len(b.assets), "depends", len(m.Depends))
+	return b, nil
+}
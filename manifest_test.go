@@ -0,0 +1,172 @@
+package engi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/paked/engi/ecs"
+)
+
+func TestManifest(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	var finished []string
+	RegisterLoader("step", AssetLoader{
+		Decode: func(r Resource) (interface{}, error) { return r.ReadAll() },
+		Finish: func(r Resource, data interface{}) (interface{}, error) {
+			finished = append(finished, r.Name())
+			return data, nil
+		},
+	})
+	defer delete(loaders, "step")
+
+	Files.Mount(fstest.MapFS{
+		"common/common.json": {Data: []byte(`{
+			"textures": [{"url": "tiles.png", "filter": "nearest"}],
+			"files": [{"url": "first.step"}]
+		}`)},
+		"common/tiles.png":  {Data: []byte(encodePNG(t, 4, 4))},
+		"common/first.step": {Data: []byte("1")},
+
+		"world1/world1.json": {Data: []byte(`{
+			"depends": ["../common/common.json"],
+			"sounds": [{"url": "music.wav", "loop": true}],
+			"levels": [{"url": "map.tmx"}],
+			"files": [{"url": "second.step"}, {"url": "tuning.json"}]
+		}`)},
+		"world1/music.wav":   {Data: []byte("RIFF")},
+		"world1/second.step": {Data: []byte("2")},
+		"world1/tuning.json": {Data: []byte(`{}`)},
+		"world1/map.tmx": {Data: []byte(tmxMap(t,
+			`<tileset firstgid="1" name="common" tilewidth="4" tileheight="4"><image source="../common/tiles.png"/></tileset>`, 1))},
+
+		"loop/a.json": {Data: []byte(`{"depends": ["b.json"]}`)},
+		"loop/b.json": {Data: []byte(`{"depends": ["a.json"]}`)},
+		"filter.json": {Data: []byte(`{"textures": [{"url": "tiles.png", "filter": "blurry"}]}`)},
+	})
+
+	if err := Files.LoadManifest("world1/world1.json"); err != nil {
+		t.Fatalf("loading the manifest should succeed, not %v", err)
+	}
+
+	if len(finished) != 2 || finished[0] != "common/first.step" || finished[1] != "world1/second.step" {
+		t.Errorf("dependencies should be finished first, not %v", finished)
+	}
+	if _, err := Files.GetLevel("map.tmx"); err != nil {
+		t.Errorf("the level should be loaded after its tileset image, not %v", err)
+	}
+	if !Files.Settings("music.wav").Loop {
+		t.Error("music.wav should loop")
+	}
+	if filter := Files.Settings("common/tiles.png").Filter; filter != "nearest" {
+		t.Errorf("tiles.png should be filtered nearest, not %q", filter)
+	}
+
+	if err := Files.Retain("world1/tuning.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Files.UnloadManifest("world1/world1.json"); err != nil {
+		t.Fatalf("unloading the manifest should succeed, not %v", err)
+	}
+	for _, name := range []string{"world1/music.wav", "world1/map.tmx"} {
+		if _, err := Get[interface{}](Files, name); !errors.Is(err, ErrMissingAsset) {
+			t.Errorf("%s should be unloaded along with its bundle, not %v", name, err)
+		}
+	}
+	if Files.Json("world1/tuning.json") == "" {
+		t.Error("assets of the bundle which are still referenced elsewhere should stay loaded")
+	}
+	if _, err := Files.GetImage("common/tiles.png"); err != nil {
+		t.Errorf("the dependencies of the bundle should stay loaded, not %v", err)
+	}
+
+	// A bundle which is unloaded before it's loaded isn't loaded anymore
+	if err := Files.AddManifest("world1/world1.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Files.UnloadManifest("world1/world1.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range Files.resources {
+		if strings.HasPrefix(r.name, "world1/") {
+			t.Errorf("%s should not be loaded anymore", r.name)
+		}
+		if strings.HasPrefix(r.name, "common/") {
+			t.Errorf("%s should not be added again, since its bundle is loaded already", r.name)
+		}
+	}
+
+	// Neither is a bundle which is unloaded while it's loading
+	if err := Files.AddManifest("world1/world1.json"); err != nil {
+		t.Fatal(err)
+	}
+	ld := Files.LoadAsync()
+	if err := Files.UnloadManifest("world1/world1.json"); err != nil {
+		t.Fatal(err)
+	}
+	ld.Wait()
+	if _, err := Files.GetSound("world1/music.wav"); !errors.Is(err, ErrMissingAsset) {
+		t.Errorf("music.wav should not be stored after its bundle has been unloaded, not %v", err)
+	}
+
+	if err := Files.AddManifest("loop/a.json"); err == nil {
+		t.Error("manifests depending on each other should fail")
+	}
+	if err := Files.AddManifest("filter.json"); err == nil {
+		t.Error("manifests with an unknown filter should fail")
+	}
+}
+
+type manifestScene struct {
+	stackScene
+	manifest string
+}
+
+func (s *manifestScene) Preload() {
+	Files.AddManifest(s.manifest)
+}
+
+func (s *manifestScene) Setup(w *ecs.World) {}
+
+func TestManifestInScene(t *testing.T) {
+	resetScenes()
+
+	Files.Mount(fstest.MapFS{
+		"pack.json":   {Data: []byte(`{"files": [{"url": "tuning.json"}]}`)},
+		"tuning.json": {Data: []byte(`{}`)},
+	})
+	refs := func() int {
+		if a, ok := Files.assets["tuning.json"]; ok {
+			return a.refs
+		}
+		return 0
+	}
+
+	game := &manifestScene{stackScene{name: "game"}, "pack.json"}
+	SetScene(game, false)
+	if refs() != 2 {
+		t.Fatalf("both the scene and the bundle should hold a reference, not %d", refs())
+	}
+
+	if err := Files.UnloadManifest("pack.json"); err != nil {
+		t.Fatal(err)
+	}
+	if refs() != 1 {
+		t.Fatalf("unloading the bundle should only release its own reference, not leave %d", refs())
+	}
+
+	// Someone else uses the asset in the meantime
+	if err := Files.Retain("tuning.json"); err != nil {
+		t.Fatal(err)
+	}
+	SetScene(&stackScene{name: "menu"}, false)
+	if err := DestroyScene(game); err != nil {
+		t.Fatal(err)
+	}
+	if refs() != 1 {
+		t.Errorf("destroying the scene should only release its own reference, not leave %d", refs())
+	}
+}
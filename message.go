@@ -1,26 +1,220 @@
 package engi
 
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
 type MessageHandler func(msg Message)
 
 type Message interface {
 	Type() string
 }
 
+// Subscription is a handle to a MessageHandler which has been registered with a MessageManager
+type Subscription struct {
+	mm          *MessageManager
+	messageType string
+	priority    int
+	handler     MessageHandler
+	active      atomic.Bool
+	// forwarder indicates the handler forwards Messages, which it shouldn't do for forwarded Messages
+	forwarder bool
+}
+
+// Unsubscribe makes sure the MessageHandler isn't called anymore, starting right away
+func (s *Subscription) Unsubscribe() {
+	if !s.active.CompareAndSwap(true, false) {
+		return
+	}
+
+	s.mm.mu.Lock()
+	defer s.mm.mu.Unlock()
+
+	// Listeners are copied on write, so they can be changed while dispatching
+	old := s.mm.listeners[s.messageType]
+	updated := make([]*Subscription, 0, len(old))
+	for _, other := range old {
+		if other != s {
+			updated = append(updated, other)
+		}
+	}
+	s.mm.listeners[s.messageType] = updated
+}
+
+// Active indicates whether or not the MessageHandler is still subscribed
+func (s *Subscription) Active() bool {
+	return s.active.Load()
+}
+
+// MessageManager delivers Messages to their listeners; it is safe for concurrent use. Dispatch calls the
+// listeners on the calling goroutine, while Post hands the Message over to the main loop.
 type MessageManager struct {
-	listeners map[string][]MessageHandler
+	mu        sync.RWMutex
+	listeners map[string][]*Subscription
+
+	queueMu sync.Mutex
+	queue   []Message
+	// spare is the backing array of the previously delivered queue, which is reused to avoid allocations
+	spare []Message
 }
 
+// Dispatch calls the listeners of the Message right away, on the current goroutine
 func (mm *MessageManager) Dispatch(message Message) {
-	handlers := mm.listeners[message.Type()]
+	mm.dispatch(message, true)
+}
+
+// dispatch calls the listeners of the Message, and forwards it to the Bus if there's a rule to do so
+func (mm *MessageManager) dispatch(message Message, forward bool) {
+	mm.mu.RLock()
+	subscriptions := mm.listeners[message.Type()]
+	mm.mu.RUnlock()
+
+	for _, s := range subscriptions {
+		if s.active.Load() && (forward || !s.forwarder) {
+			s.handler(message)
+		}
+	}
+
+	// Forwarded messages are not forwarded again, so rules in both directions don't loop
+	if forward && mm != Bus && forwardsToBus(message.Type()) {
+		Bus.dispatch(message, false)
+	}
+}
+
+// Post queues the Message, so its listeners are called on the main loop at the end of the frame, after the
+// Systems of the Scene have been updated. This is the way to send Messages from other goroutines, to the
+// Bus, or to the Mailbox of a Scene that has been captured on the main thread:
+//
+//	func (s *Game) Setup(w *ecs.World) {
+//		mailbox := engi.Mailbox
+//		go func() {
+//			mailbox.Post(LoadedMessage{})
+//		}()
+//	}
+//
+// The Mailbox variable itself must not be read from other goroutines. Messages posted while the queue is
+// being delivered, are delivered the next frame.
+func (mm *MessageManager) Post(message Message) {
+	mm.queueMu.Lock()
+	mm.queue = append(mm.queue, message)
+	mm.queueMu.Unlock()
+}
 
-	for _, handler := range handlers {
-		handler(message)
+// deliver dispatches the Messages that have been posted
+func (mm *MessageManager) deliver() {
+	mm.queueMu.Lock()
+	queue := mm.queue
+	mm.queue = mm.spare
+	mm.queueMu.Unlock()
+
+	for i, message := range queue {
+		mm.Dispatch(message)
+		queue[i] = nil
 	}
+
+	mm.queueMu.Lock()
+	mm.spare = queue[:0]
+	mm.queueMu.Unlock()
 }
 
-func (mm *MessageManager) Listen(messageType string, handler MessageHandler) {
+// Listen calls handler for every Message of the given type, until it is unsubscribed
+func (mm *MessageManager) Listen(messageType string, handler MessageHandler) *Subscription {
+	return mm.ListenWithPriority(messageType, 0, handler)
+}
+
+// ListenWithPriority is like Listen, but listeners with a lower priority are called first. Listeners with
+// the same priority are called in the order in which they were added.
+func (mm *MessageManager) ListenWithPriority(messageType string, priority int, handler MessageHandler) *Subscription {
+	return mm.subscribe(&Subscription{messageType: messageType, priority: priority, handler: handler})
+}
+
+// subscribe adds the Subscription to the listeners of its message type
+func (mm *MessageManager) subscribe(s *Subscription) *Subscription {
+	s.mm = mm
+	s.active.Store(true)
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
 	if mm.listeners == nil {
-		mm.listeners = make(map[string][]MessageHandler)
+		mm.listeners = make(map[string][]*Subscription)
+	}
+
+	old := mm.listeners[s.messageType]
+	updated := make([]*Subscription, 0, len(old)+1)
+	i := 0
+	for ; i < len(old) && old[i].priority <= s.priority; i++ {
+		updated = append(updated, old[i])
+	}
+	updated = append(updated, s)
+	updated = append(updated, old[i:]...)
+	mm.listeners[s.messageType] = updated
+
+	return s
+}
+
+// reset removes all listeners and queued Messages
+func (mm *MessageManager) reset() {
+	mm.mu.Lock()
+	for _, subscriptions := range mm.listeners {
+		for _, s := range subscriptions {
+			s.active.Store(false)
+		}
 	}
-	mm.listeners[messageType] = append(mm.listeners[messageType], handler)
+	mm.listeners = make(map[string][]*Subscription)
+	mm.mu.Unlock()
+
+	mm.queueMu.Lock()
+	mm.queue = nil
+	mm.queueMu.Unlock()
+}
+
+// Listen calls handler for every Message of type T, without the need for a type assertion:
+//
+//	engi.Listen(engi.Mailbox, func(msg engi.CollisionMessage) {
+//		// ...
+//	})
+func Listen[T Message](mm *MessageManager, handler func(T)) *Subscription {
+	return ListenWithPriority(mm, 0, handler)
+}
+
+// ListenWithPriority is like Listen, but listeners with a lower priority are called first. T has to be a
+// concrete type of Message, since an interface doesn't tell which type of Messages to listen to; it panics
+// otherwise.
+func ListenWithPriority[T Message](mm *MessageManager, priority int, handler func(T)) *Subscription {
+	return mm.ListenWithPriority(messageTypeOf[T](), priority, func(msg Message) {
+		if typed, ok := msg.(T); ok {
+			handler(typed)
+		}
+	})
+}
+
+// messageTypes holds the message type of every type of Message that has been listened to using Listen
+var messageTypes sync.Map
+
+// messageTypeOf returns the message type of T, which it gets from a new value rather than the zero value of
+// T, so Messages which are pointers can read their fields in Type
+func messageTypeOf[T Message]() string {
+	t := reflect.TypeFor[T]()
+	if messageType, ok := messageTypes.Load(t); ok {
+		return messageType.(string)
+	}
+
+	var msg Message
+	switch t.Kind() {
+	case reflect.Interface:
+		panic(fmt.Sprintf("engi: can't listen to Messages of interface type %v, use the Listen method of MessageManager instead", t))
+	case reflect.Pointer:
+		msg = reflect.New(t.Elem()).Interface().(Message)
+	default:
+		var zero T
+		msg = zero
+	}
+
+	messageType := msg.Type()
+	messageTypes.Store(t, messageType)
+	return messageType
 }
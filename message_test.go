@@ -0,0 +1,182 @@
+package engi
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMessagePriority(t *testing.T) {
+	mm := &MessageManager{}
+
+	var calls []string
+	mm.ListenWithPriority("testMessage", 1, func(Message) { calls = append(calls, "late") })
+	mm.ListenWithPriority("testMessage", -1, func(Message) { calls = append(calls, "early") })
+	mm.Listen("testMessage", func(Message) { calls = append(calls, "first") })
+	mm.Listen("testMessage", func(Message) { calls = append(calls, "second") })
+
+	mm.Dispatch(testMessage{})
+
+	expected := "early first second late"
+	if got := strings.Join(calls, " "); got != expected {
+		t.Errorf("listeners should be called as %q, not %q", expected, got)
+	}
+}
+
+func TestMessageUnsubscribe(t *testing.T) {
+	mm := &MessageManager{}
+
+	var count int
+	var sub *Subscription
+	sub = mm.Listen("testMessage", func(Message) {
+		count++
+		sub.Unsubscribe()
+	})
+	other := mm.Listen("testMessage", func(Message) { count++ })
+
+	mm.Dispatch(testMessage{})
+	mm.Dispatch(testMessage{})
+
+	if count != 3 {
+		t.Errorf("listeners should be called 3 times, not %d", count)
+	}
+	if sub.Active() || !other.Active() {
+		t.Error("only the unsubscribed listener should be inactive")
+	}
+}
+
+func TestMessageTyped(t *testing.T) {
+	mm := &MessageManager{}
+
+	var received []CameraMessage
+	Listen(mm, func(msg CameraMessage) {
+		received = append(received, msg)
+	})
+
+	mm.Dispatch(CameraMessage{Axis: XAxis, Value: 2})
+	mm.Dispatch(testMessage{})
+
+	if len(received) != 1 || received[0].Value != 2 {
+		t.Errorf("typed listener should receive only the CameraMessage, not %v", received)
+	}
+}
+
+// namedMessage is a Message of which the type is read from a field
+type namedMessage struct {
+	name string
+}
+
+func (m *namedMessage) Type() string {
+	if m.name == "" {
+		return "namedMessage"
+	}
+	return m.name
+}
+
+func TestMessageTypedPointer(t *testing.T) {
+	mm := &MessageManager{}
+
+	var received int
+	Listen(mm, func(*namedMessage) { received++ })
+	mm.Dispatch(&namedMessage{})
+
+	if received != 1 {
+		t.Errorf("typed listener of a pointer type should receive the message once, not %d times", received)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("listening to an interface type should panic")
+		}
+	}()
+	Listen(mm, func(Message) {})
+}
+
+func TestMessagePost(t *testing.T) {
+	mm := &MessageManager{}
+
+	var count int
+	mm.Listen("testMessage", func(Message) {
+		count++
+		if count == 1 {
+			mm.Post(testMessage{})
+		}
+	})
+
+	mm.Post(testMessage{})
+	if count != 0 {
+		t.Errorf("posted message should not be delivered right away, was delivered %d times", count)
+	}
+
+	mm.deliver()
+	if count != 1 {
+		t.Errorf("posted message should be delivered once, not %d times", count)
+	}
+
+	mm.deliver()
+	if count != 2 {
+		t.Errorf("message posted while delivering should be delivered the next time, count was %d", count)
+	}
+}
+
+func TestMessageConcurrentPost(t *testing.T) {
+	mm := &MessageManager{}
+
+	var count int
+	mm.Listen("testMessage", func(Message) { count++ })
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				mm.Post(testMessage{})
+				sub := mm.Listen("otherMessage", func(Message) {})
+				mm.Dispatch(CameraMessage{})
+				sub.Unsubscribe()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Deliver on this goroutine, like the main loop does, while the others keep posting
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		mm.deliver()
+	}
+
+	if count != 800 {
+		t.Errorf("all 800 posted messages should be delivered, not %d", count)
+	}
+}
+
+func TestMessageAllocations(t *testing.T) {
+	mm := &MessageManager{}
+	mm.Listen("testMessage", func(Message) {})
+
+	var msg Message = testMessage{}
+
+	// Warm up the queue, so its backing arrays exist
+	mm.Post(msg)
+	mm.deliver()
+	mm.Post(msg)
+	mm.deliver()
+
+	if n := testing.AllocsPerRun(100, func() { mm.Dispatch(msg) }); n != 0 {
+		t.Errorf("Dispatch should not allocate, but did %v times", n)
+	}
+
+	if n := testing.AllocsPerRun(100, func() { mm.Post(msg); mm.deliver() }); n != 0 {
+		t.Errorf("Post and deliver should not allocate, but did %v times", n)
+	}
+}
@@ -0,0 +1,87 @@
+package engi
+
+import (
+	"io/fs"
+	"time"
+)
+
+// AssetReloadedMessage is dispatched on the Mailbox of every Scene which has been set up, whenever an asset has
+// been reloaded, because its file changed while hot reloading was enabled
+type AssetReloadedMessage struct {
+	Name string
+	URL  string
+}
+
+func (AssetReloadedMessage) Type() string {
+	return "AssetReloadedMessage"
+}
+
+// SetHotReload makes the Loader check the files of all loaded assets for changes every interval, and reload
+// those which changed in place, which is meant for development. Textures and Levels are updated in place, so
+// existing references see the new data, and RenderComponents of every Scene regenerate their buffers when
+// drawn; an AssetReloadedMessage on the Mailbox of every Scene announces every reload. An interval of 0
+// disables hot reloading, which is the default.
+func (l *Loader) SetHotReload(interval time.Duration) {
+	l.reloadInterval = interval
+	l.lastReloadPoll = time.Now()
+}
+
+// pollReload finishes the assets which are being reloaded, and checks for changed files once the interval has
+// passed; it is called every frame, on the main thread
+func (l *Loader) pollReload() {
+	if l.reloading != nil {
+		if l.reloading.Poll() {
+			l.reloading = nil
+		}
+		return
+	}
+
+	if l.reloadInterval <= 0 || time.Since(l.lastReloadPoll) < l.reloadInterval {
+		return
+	}
+	l.lastReloadPoll = time.Now()
+
+	fsys := l.FS()
+	var changed []Resource
+	for _, a := range l.assets {
+		info, err := fs.Stat(fsys, a.url)
+		if err != nil || !info.ModTime().After(a.modTime) {
+			continue
+		}
+		// Don't try again until the file changes again, even if it turns out to be broken
+		a.modTime = info.ModTime()
+		changed = append(changed, a.Resource)
+	}
+
+	if len(changed) > 0 {
+		l.reloading = l.startLoading(changed, true)
+	}
+}
+
+// reload updates the loaded asset with the newly decoded data; assets which have been unloaded in the
+// meantime are ignored
+func (l *Loader) reload(r Resource, loader AssetLoader, data interface{}) error {
+	a, ok := l.assets[r.name]
+	if !ok || a.url != r.url {
+		return nil
+	}
+
+	if loader.Reload != nil {
+		if err := loader.Reload(a.data, r, data); err != nil {
+			return err
+		}
+	} else {
+		if loader.Finish != nil {
+			var err error
+			if data, err = loader.Finish(r, data); err != nil {
+				return err
+			}
+		}
+		l.unload(a)
+		a.data = data
+	}
+
+	logInfo(LogAssets, "reloaded asset", "name", r.name, "url", r.url)
+	dispatchToScenes(AssetReloadedMessage{r.name, r.url})
+	return nil
+}
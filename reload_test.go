@@ -0,0 +1,109 @@
+package engi
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func encodePNG(t *testing.T, w, h int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// touch rewrites the file, with a modification time that is guaranteed to be newer
+func touch(t *testing.T, file, content string) {
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, future, future); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHotReload(t *testing.T) {
+	resetScenes()
+
+	dir := writeFiles(t, map[string]string{
+		"tuning.json": `{"speed": 1}`,
+		"hero.png":    encodePNG(t, 4, 4),
+	})
+	defer os.RemoveAll(dir)
+
+	game := &loadScene{stackScene: stackScene{name: "game"}, urls: []string{
+		filepath.Join(dir, "tuning.json"), filepath.Join(dir, "hero.png"),
+	}}
+	SetScene(game, false)
+
+	hero := Files.Image("hero.png")
+	if hero == nil {
+		t.Fatal("hero.png should be loaded")
+	}
+	ren := NewRenderComponent(hero, Point{1, 1}, "hero")
+
+	var reloaded []string
+	Listen(Mailbox, func(msg AssetReloadedMessage) {
+		reloaded = append(reloaded, msg.Name)
+	})
+
+	// Scenes underneath others are told about reloads as well
+	hud := &stackScene{name: "hud"}
+	if err := PushScene(hud, UnderlayVisible, nil); err != nil {
+		t.Fatal(err)
+	}
+	var hudReloaded int
+	Listen(Mailbox, func(AssetReloadedMessage) {
+		if CurrentScene() != hud {
+			t.Error("the scene should be active while it's told about a reload")
+		}
+		hudReloaded++
+	})
+
+	Files.SetHotReload(time.Millisecond)
+	defer Files.SetHotReload(0)
+
+	touch(t, filepath.Join(dir, "tuning.json"), `{"speed": 2}`)
+	touch(t, filepath.Join(dir, "hero.png"), encodePNG(t, 8, 2))
+
+	for i := 0; i < 100 && len(reloaded) < 2; i++ {
+		time.Sleep(2 * time.Millisecond)
+		updateScenes()
+	}
+
+	if len(reloaded) != 2 {
+		t.Fatalf("both assets should be reloaded, not %v", reloaded)
+	}
+	if hudReloaded != 2 {
+		t.Errorf("every scene should be told about both reloads, not %d", hudReloaded)
+	}
+	if Files.Json("tuning.json") != `{"speed": 2}` {
+		t.Errorf("tuning.json should be reloaded, not %q", Files.Json("tuning.json"))
+	}
+	if Files.Image("hero.png") != hero {
+		t.Error("hero.png should be reloaded in place")
+	}
+	if hero.Width() != 8 || hero.Height() != 2 {
+		t.Errorf("hero.png should be 8x2 after reloading, not %vx%v", hero.Width(), hero.Height())
+	}
+	if !ren.stale() {
+		t.Error("the buffer drawing hero.png should be stale after reloading it, whichever scene it is in")
+	}
+
+	// Nothing changed since, so nothing should be reloaded again
+	for i := 0; i < 5; i++ {
+		time.Sleep(2 * time.Millisecond)
+		updateScenes()
+	}
+	if len(reloaded) != 2 {
+		t.Errorf("unchanged assets should not be reloaded, but were: %v", reloaded)
+	}
+}
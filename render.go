@@ -51,6 +51,10 @@ type RenderComponent struct {
 	drawable      Drawable
 	buffer        *webgl.Buffer
 	bufferContent []float32
+
+	// drawnView and drawnSize are the view and size of the drawable the buffer was generated for
+	drawnView [4]float32
+	drawnSize Point
 }
 
 func NewRenderComponent(d Drawable, scale Point, label string) *RenderComponent {
@@ -86,19 +90,46 @@ func (r *RenderComponent) Scale() Point {
 	return r.scale
 }
 
+// SetTransparency sets the Transparency, and updates the buffer accordingly
+func (r *RenderComponent) SetTransparency(t float32) {
+	r.Transparency = t
+	r.preloadTexture()
+}
+
+// SetColor sets the Color with which the Drawable is tinted, and updates the buffer accordingly
+func (r *RenderComponent) SetColor(c color.Color) {
+	r.Color = c
+	r.preloadTexture()
+}
+
 func (*RenderComponent) Type() string {
 	return "RenderComponent"
 }
 
 // Init is called to initialize the RenderElement
 func (ren *RenderComponent) preloadTexture() {
-	if ren.drawable == nil || headless {
+	if ren.drawable == nil {
+		return
+	}
+
+	// SVGs are rasterised again when they are scaled up, rather than stretched
+	if svg, ok := ren.drawable.(*SVG); ok {
+		svg.drawnAt(ren.scale)
+	}
+
+	u, v, u2, v2 := ren.drawable.View()
+	ren.drawnView = [4]float32{u, v, u2, v2}
+	ren.drawnSize = Point{ren.drawable.Width(), ren.drawable.Height()}
+
+	if headless {
 		return
 	}
 
 	ren.bufferContent = ren.generateBufferContent()
 
-	ren.buffer = Gl.CreateBuffer()
+	if ren.buffer == nil {
+		ren.buffer = Gl.CreateBuffer()
+	}
 	Gl.BindBuffer(Gl.ARRAY_BUFFER, ren.buffer)
 	Gl.BufferData(Gl.ARRAY_BUFFER, ren.bufferContent, Gl.STATIC_DRAW)
 
@@ -106,13 +137,21 @@ func (ren *RenderComponent) preloadTexture() {
 	// ren.bufferContent = make([]float32, 0)
 }
 
+// stale returns whether the view or size of the drawable changed since the buffer was generated, which
+// happens when its texture is reloaded, resized or packed into an atlas, even while its scene isn't the
+// current one
+func (ren *RenderComponent) stale() bool {
+	u, v, u2, v2 := ren.drawable.View()
+	return [4]float32{u, v, u2, v2} != ren.drawnView || (Point{ren.drawable.Width(), ren.drawable.Height()}) != ren.drawnSize
+}
+
 // generateBufferContent computes information about the 4 vertices needed to draw the texture, which should
 // be stored in the buffer
 func (ren *RenderComponent) generateBufferContent() []float32 {
 	scaleX := ren.scale.X
 	scaleY := ren.scale.Y
 	rotation := float32(0.0)
-	transparency := float32(1.0)
+	transparency := ren.Transparency
 	c := ren.Color
 
 	fx := float32(0)
@@ -120,9 +159,16 @@ func (ren *RenderComponent) generateBufferContent() []float32 {
 	fx2 := ren.drawable.Width()
 	fy2 := ren.drawable.Height()
 
+	// Trimmed regions are drawn at the part of their original size which wasn't cut off
+	region, isRegion := ren.drawable.(*Region)
+	if isRegion && region.trimmed {
+		fx, fy = region.trimX, region.trimY
+		fx2, fy2 = fx+region.trimW, fy+region.trimH
+	}
+
 	if scaleX != 1 || scaleY != 1 {
-		//fx *= scaleX
-		//fy *= scaleY
+		fx *= scaleX
+		fy *= scaleY
 		fx2 *= scaleX
 		fy2 *= scaleY
 	}
@@ -178,15 +224,20 @@ func (ren *RenderComponent) generateBufferContent() []float32 {
 
 	colorR, colorG, colorB, _ := c.RGBA()
 
-	red := colorR
-	green := colorG << 8
-	blue := colorB << 16
+	red := colorR >> 8
+	green := (colorG >> 8) << 8
+	blue := (colorB >> 8) << 16
 	alpha := uint32(transparency*255.0) << 24
 
 	tint := math.Float32frombits((alpha | blue | green | red) & 0xfeffffff)
 
 	u, v, u2, v2 := ren.drawable.View()
 
+	// Rotated regions are stored rotated clockwise, so the top left corner is at the top right of the view
+	if isRegion && region.rotated {
+		return []float32{x1, y1, u2, v, tint, x4, y4, u2, v2, tint, x3, y3, u, v2, tint, x2, y2, u, v, tint}
+	}
+
 	return []float32{x1, y1, u, v, tint, x4, y4, u2, v, tint, x3, y3, u2, v2, tint, x2, y2, u, v2, tint}
 }
 
@@ -196,6 +247,8 @@ type RenderSystem struct {
 	renders map[PriorityLevel][]*ecs.Entity
 	changed bool
 	world   *ecs.World
+
+	subscriptions []*Subscription
 }
 
 func (rs *RenderSystem) New(w *ecs.World) {
@@ -218,9 +271,14 @@ func (rs *RenderSystem) New(w *ecs.World) {
 		}
 	}
 
-	Mailbox.Listen("renderChangeMessage", func(m Message) {
-		rs.changed = true
-	})
+	for _, sub := range rs.subscriptions {
+		sub.Unsubscribe()
+	}
+	rs.subscriptions = []*Subscription{
+		Listen(Mailbox, func(renderChangeMessage) {
+			rs.changed = true
+		}),
+	}
 }
 
 func (rs *RenderSystem) AddEntity(e *ecs.Entity) {
@@ -234,10 +292,6 @@ func (rs *RenderSystem) RemoveEntity(e *ecs.Entity) {
 }
 
 func (rs *RenderSystem) Pre() {
-	if !headless {
-		Gl.Clear(Gl.COLOR_BUFFER_BIT)
-	}
-
 	if !rs.changed {
 		return
 	}
@@ -283,6 +337,11 @@ func (rs *RenderSystem) Post() {
 				continue // with other entities
 			}
 
+			// The size and view of the texture are part of the buffer
+			if render.stale() {
+				render.preloadTexture()
+			}
+
 			s.Draw(render.drawable.Texture(), render.buffer, space.Position.X, space.Position.Y, 0) // TODO: add rotation
 		}
 	}
@@ -1,7 +1,9 @@
 package engi
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/paked/engi/ecs"
 )
@@ -13,7 +15,9 @@ var (
 // Scene represents a screen ingame.
 // i.e.: main menu, settings, but also the game itself
 type Scene interface {
-	// Preload is called before loading resources
+	// Preload is called before loading resources, whenever a new World is created for the Scene. The assets
+	// loaded for it are released when the World is thrown away, which happens once the Scene has left the
+	// stack, unless it's a PersistentScene.
 	Preload()
 
 	// Setup is called before the main loop
@@ -29,11 +33,81 @@ type Scene interface {
 	Type() string
 }
 
+// PersistentScene can be implemented by a Scene which keeps its World, and the assets that were loaded for
+// it, after it has left the stack, i.e. a main menu that is returned to often. Other Scenes are destroyed
+// like using DestroyScene once they have left the stack, and any Transition is done, so the assets which
+// only they used are unloaded; they are set up again the next time they are used.
+type PersistentScene interface {
+	Persistent() bool
+}
+
+// LoadErrorHandler can be implemented by a Scene that wants to know which of its resources could not be
+// loaded. LoadErrors is called right before Setup, and only if something went wrong; in Strict mode, Setup
+// isn't called afterwards.
+type LoadErrorHandler interface {
+	LoadErrors(errs []*ResourceError)
+}
+
 type sceneWrapper struct {
-	scene   Scene
-	world   *ecs.World
-	mailbox *MessageManager
-	camera  *cameraSystem
+	scene      Scene
+	world      *ecs.World
+	mailbox    *MessageManager
+	camera     *cameraSystem
+	scheduler  *Scheduler
+	coroutines *CoroutineSystem
+
+	// loading is set while the resources of the Scene are loading; Setup is called once it's done
+	loading *Loading
+	// placeholder is the loading Scene, which is displayed in the meantime
+	placeholder *sceneWrapper
+	// effect is used when rendering the Scene, while a Transition is running
+	effect SceneEffect
+	// assets are the names of the assets the Scene holds a reference to, which are released when its World
+	// is thrown away
+	assets []string
+}
+
+// Underlay determines what happens to the Scenes underneath a Scene that was pushed using PushScene. The
+// values can be combined, i.e. UnderlayUpdating | UnderlayVisible keeps the Scenes underneath running.
+type Underlay uint8
+
+const (
+	// UnderlayPaused stops updating and rendering the Scenes underneath
+	UnderlayPaused Underlay = 0
+	// UnderlayUpdating keeps updating the Scenes underneath, without rendering them
+	UnderlayUpdating Underlay = 1 << 0
+	// UnderlayVisible keeps rendering the Scenes underneath, without updating them
+	UnderlayVisible Underlay = 1 << 1
+)
+
+type stackedScene struct {
+	wrapper  *sceneWrapper
+	underlay Underlay
+}
+
+var (
+	// sceneStack holds the active Scene on top, and the Scenes it was pushed onto underneath
+	sceneStack []stackedScene
+	// sceneStackChanges is incremented whenever the stack changes, so updateScenes can tell
+	sceneStackChanges int
+
+	transitioning *transitionState
+
+	// sceneEffect is the SceneEffect of the Scene that is currently being rendered
+	sceneEffect = noEffect
+
+	// loadingScene is displayed instead of Scenes whose resources are still loading
+	loadingScene Scene
+
+	// leftScenes have left the stack, and are destroyed once they aren't part of a Transition anymore
+	leftScenes []*sceneWrapper
+)
+
+// SetLoadingScene sets the Scene that is displayed while the resources of other Scenes are loading, so the
+// window doesn't freeze. Its own resources are loaded the first time it's needed, before anything else.
+// The progress can be read from Files.Loading(). If s is nil, resources are loaded before switching Scenes.
+func SetLoadingScene(s Scene) {
+	loadingScene = s
 }
 
 // CurrentScene returns the SceneWorld that is currently active
@@ -43,12 +117,105 @@ func CurrentScene() Scene {
 
 // SetScene sets the currentScene to the given Scene, and
 // optionally forcing to create a new ecs.World that goes with it.
+// Any Scenes that were pushed using PushScene are removed as well.
+// The Scenes which are left are destroyed, unless they are a PersistentScene; see PersistentScene.
 func SetScene(s Scene, forceNewWorld bool) {
+	SetSceneWithTransition(s, forceNewWorld, nil)
+}
+
+// SetSceneWithTransition is like SetScene, but uses the Transition to switch between the Scenes
+func SetSceneWithTransition(s Scene, forceNewWorld bool, t Transition) {
+	before := visibleScenes()
+
 	// Break down currentScene
 	if currentScene != nil {
-		currentScene.Hide()
+		hideScene(currentScene)
+	}
+
+	left := sceneStack
+
+	wrapper := prepareScene(s, forceNewWorld)
+	sceneStack = []stackedScene{{wrapper: wrapper}}
+	sceneStackChanges++
+	wrapper.show()
+
+	startTransition(t, before, false)
+
+	for _, stacked := range left {
+		leftScenes = append(leftScenes, stacked.wrapper)
 	}
+	destroyLeftScenes()
+}
+
+// PushScene makes s the active Scene, on top of the current one. The Underlay determines whether the
+// Scenes underneath are still updated and / or rendered. PopScene returns to the Scene underneath.
+func PushScene(s Scene, underlay Underlay, t Transition) error {
+	for _, stacked := range sceneStack {
+		if stacked.wrapper.scene == s {
+			return fmt.Errorf("scene already on the stack: %s", s.Type())
+		}
+	}
+
+	before := visibleScenes()
+
+	if currentScene != nil {
+		hideScene(currentScene)
+	}
+
+	wrapper := prepareScene(s, false)
+	sceneStack = append(sceneStack, stackedScene{wrapper: wrapper, underlay: underlay})
+	sceneStackChanges++
+	wrapper.show()
 
+	startTransition(t, before, false)
+	return nil
+}
+
+// PopScene removes the active Scene, and returns to the Scene underneath. The removed Scene is destroyed,
+// unless it is a PersistentScene, which keeps its World so it can be pushed again later.
+func PopScene(t Transition) error {
+	if len(sceneStack) < 2 {
+		return errors.New("no scene to return to")
+	}
+
+	before := visibleScenes()
+
+	hideScene(currentScene)
+
+	popped := sceneStack[len(sceneStack)-1].wrapper
+	sceneStack[len(sceneStack)-1] = stackedScene{}
+	sceneStack = sceneStack[:len(sceneStack)-1]
+	sceneStackChanges++
+	sceneStack[len(sceneStack)-1].wrapper.show()
+
+	startTransition(t, before, true)
+
+	leftScenes = append(leftScenes, popped)
+	destroyLeftScenes()
+	return nil
+}
+
+// destroyLeftScenes destroys the Scenes which have left the stack, once no Transition is rendering them
+// anymore; Scenes which have returned to the stack in the meantime are kept, and so is the loading Scene
+func destroyLeftScenes() {
+	if transitioning != nil {
+		return
+	}
+
+	left := leftScenes
+	leftScenes = nil
+	for _, w := range left {
+		if p, ok := w.scene.(PersistentScene); ok && p.Persistent() || w.scene == loadingScene {
+			continue
+		}
+		if err := DestroyScene(w.scene); err == nil {
+			logDebug(LogEngine, "destroyed scene which has left the stack", "scene", w.scene.Type())
+		}
+	}
+}
+
+// prepareScene registers the Scene and (re)initializes it if needed, and makes it the current one
+func prepareScene(s Scene, forceNewWorld bool) *sceneWrapper {
 	// Register Scene if needed
 	wrapper, registered := scenes[s.Type()]
 	if !registered {
@@ -59,39 +226,311 @@ func SetScene(s Scene, forceNewWorld bool) {
 	// Initialize new Scene / World if needed
 	var doSetup bool
 
+	// The assets of the previous World are released after loading, so those which are still used stay loaded
+	var previousAssets []string
+
 	if wrapper.world == nil || forceNewWorld {
+		previousAssets = wrapper.destroy()
+
 		wrapper.world = &ecs.World{}
 		wrapper.mailbox = &MessageManager{}
 		wrapper.camera = &cameraSystem{}
+		wrapper.scheduler = &Scheduler{}
+		wrapper.coroutines = &CoroutineSystem{}
 
 		doSetup = true
 	}
 
-	// Do the switch
-	currentScene = s
-	currentWorld = wrapper.world
-	Mailbox = wrapper.mailbox
-	cam = wrapper.camera
-
 	// doSetup is true whenever we're (re)initializing the Scene
 	if doSetup {
+		// The loading Scene has to be ready before we start loading anything else
+		wrapper.placeholder = nil
+		if loadingScene != nil && loadingScene != s {
+			wrapper.placeholder = prepareScene(loadingScene, false)
+		}
+
+		wrapper.activate()
 		s.Preload()
-		Files.Load(func() {})
+		wrapper.loading = Files.LoadAsync()
+		Files.Release(previousAssets...)
+
+		if wrapper.placeholder == nil {
+			wrapper.loading.Wait()
+			wrapper.setup()
+		}
+	}
+
+	// Do the switch
+	wrapper.displayed().activate()
+
+	return wrapper
+}
+
+// setup calls Setup, once the resources of the Scene have been loaded
+func (w *sceneWrapper) setup() {
+	ld := w.loading
+	w.loading = nil
+	w.assets = ld.retained
+
+	w.activate()
+	w.mailbox.reset()
+
+	w.world.New()
+	w.world.AddSystem(w.camera)
+	w.world.AddSystem(w.scheduler)
+	w.world.AddSystem(w.coroutines)
+
+	if errs := ld.Errors(); len(errs) > 0 {
+		if h, ok := w.scene.(LoadErrorHandler); ok {
+			h.LoadErrors(errs)
+		}
+		Bus.Dispatch(SceneLoadFailedMessage{w.scene, &LoadError{errs}})
+
+		if Files.Strict {
+			logError(LogEngine, "cannot set up scene", "scene", w.scene.Type(), "err", ld.Err())
+			return
+		}
+	}
 
-		wrapper.mailbox.listeners = make(map[string][]MessageHandler)
+	w.scene.Setup(w.world)
+}
+
+// destroy throws away the World of the Scene, and returns the names of the assets it was using, which
+// have to be released by the caller
+func (w *sceneWrapper) destroy() []string {
+	if w.loading != nil {
+		w.loading.Wait()
+		w.assets = append(w.assets, w.loading.retained...)
+		w.loading = nil
+	}
+	if w.world != nil {
+		Bus.Dispatch(SceneDestroyedMessage{w.scene})
+		w.world = nil
+	}
+	if w.coroutines != nil {
+		w.coroutines.cancelAll()
+	}
+
+	assets := w.assets
+	w.assets = nil
+	return assets
+}
+
+// DestroyScene throws away the World of the Scene, and releases the assets which were loaded for it, so
+// those which no other Scene uses are unloaded. The Scene is set up again the next time it is used. Scenes
+// can't be destroyed while they are on the stack, or leaving it during a Transition.
+func DestroyScene(s Scene) error {
+	wrapper, registered := scenes[s.Type()]
+	if !registered {
+		return nil
+	}
 
-		wrapper.world.New()
-		wrapper.world.AddSystem(wrapper.camera)
+	for _, stacked := range sceneStack {
+		if stacked.wrapper == wrapper || stacked.wrapper.placeholder == wrapper && stacked.wrapper.loading != nil {
+			return fmt.Errorf("scene is in use: %s", s.Type())
+		}
+	}
+	if transitioning != nil {
+		for _, leaving := range transitioning.leaving {
+			if leaving == wrapper {
+				return fmt.Errorf("scene is in use: %s", s.Type())
+			}
+		}
+	}
 
-		s.Setup(wrapper.world)
+	Files.Release(wrapper.destroy()...)
+	return nil
+}
+
+// displayed returns the Scene that is displayed in place of this one, which is the loading Scene while
+// its resources are loading
+func (w *sceneWrapper) displayed() *sceneWrapper {
+	if w.loading != nil {
+		return w.placeholder
 	}
+	return w
+}
+
+// show makes the Scene (or the loading Scene in its place) the current one, and calls its Show
+func (w *sceneWrapper) show() {
+	d := w.displayed()
+	d.activate()
+	showScene(d.scene)
+}
+
+// activate points the globals to the Scene, so it can be updated or rendered
+func (w *sceneWrapper) activate() {
+	currentScene = w.scene
+	currentWorld = w.world
+	Mailbox = w.mailbox
+	cam = w.camera
+	scheduler = w.scheduler
+	coroutines = w.coroutines
+	sceneEffect = w.effect
+}
+
+// dispatchToScenes dispatches the Message on the Mailbox of every Scene which has been set up, in the order of
+// their names, with the Scene active like when it is updated. It's meant for changes which affect every Scene,
+// like assets that are shared between them.
+func dispatchToScenes(message Message) {
+	names := make([]string, 0, len(scenes))
+	for name, w := range scenes {
+		if w.world != nil && w.loading == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	active := currentScene
+	for _, name := range names {
+		w := scenes[name]
+		w.activate()
+		w.mailbox.Dispatch(message)
+	}
+	if active != nil {
+		if w, ok := scenes[active.Type()]; ok {
+			w.activate()
+		}
+	}
+}
+
+// sceneLayers reports, for every Scene on the stack, whether it should be updated and rendered
+func sceneLayers() (update, render []bool) {
+	update = make([]bool, len(sceneStack))
+	render = make([]bool, len(sceneStack))
+
+	for i := len(sceneStack) - 1; i >= 0; i-- {
+		if i == len(sceneStack)-1 {
+			update[i], render[i] = true, true
+			continue
+		}
+
+		above := sceneStack[i+1].underlay
+		update[i] = update[i+1] && above&UnderlayUpdating != 0
+		render[i] = render[i+1] && above&UnderlayVisible != 0
+	}
+
+	return
+}
+
+// visibleScenes returns the Scenes on the stack which are being rendered, from the bottom up
+func visibleScenes() []*sceneWrapper {
+	var visible []*sceneWrapper
+
+	_, render := sceneLayers()
+	for i, stacked := range sceneStack {
+		if render[i] {
+			visible = append(visible, stacked.wrapper.displayed())
+		}
+	}
+
+	return visible
+}
+
+func isRenderer(s ecs.Systemer) bool {
+	_, ok := s.(*RenderSystem)
+	return ok
+}
+
+func isNotRenderer(s ecs.Systemer) bool {
+	return !isRenderer(s)
+}
+
+// renderScenes renders the Scenes without updating them
+func renderScenes(wrappers []*sceneWrapper) {
+	for _, w := range wrappers {
+		w.activate()
+		w.world.UpdateSystems(0, 0, isRenderer)
+	}
+}
+
+// updateScenes updates and renders the Scenes on the stack, and any Scenes that are leaving
+// during a Transition, from the bottom up
+func updateScenes() {
+	if !headless {
+		Gl.Clear(Gl.COLOR_BUFFER_BIT)
+	}
+
+	Files.pollReload()
+
+	if len(sceneStack) == 0 {
+		return
+	}
+
+	// Scenes that are done loading can be set up, and replace the loading Scene
+	changes := sceneStackChanges
+	for i, stacked := range sceneStack {
+		w := stacked.wrapper
+		if w.loading == nil || !w.loading.Poll() {
+			continue
+		}
+
+		top := i == len(sceneStack)-1
+		if top {
+			hideScene(w.displayed().scene)
+		}
+		w.setup()
+		if top && changes == sceneStackChanges {
+			w.show()
+		}
+
+		if changes != sceneStackChanges {
+			return
+		}
+	}
+
+	ts := transitioning
+	if ts != nil && !ts.advance(Time.UnscaledDelta()) {
+		transitioning = nil
+	}
+
+	if ts != nil && !ts.leavingOnTop {
+		renderScenes(ts.leaving)
+	}
+
+	update, render := sceneLayers()
+	for i, stacked := range sceneStack {
+		// Systems may have switched Scenes; the new stack gets updated during the next frame
+		if changes != sceneStackChanges {
+			break
+		}
+
+		w := stacked.wrapper.displayed()
+		w.activate()
+
+		switch {
+		case update[i] && render[i]:
+			w.world.UpdateScaled(Time.Delta(), Time.UnscaledDelta())
+		case update[i]:
+			w.world.UpdateSystems(Time.Delta(), Time.UnscaledDelta(), isNotRenderer)
+		case render[i]:
+			w.world.UpdateSystems(0, 0, isRenderer)
+		}
+
+		if update[i] {
+			w.mailbox.deliver()
+		}
+	}
+
+	if ts != nil && ts.leavingOnTop && changes == sceneStackChanges {
+		renderScenes(ts.leaving)
+	}
+
+	if len(leftScenes) > 0 && changes == sceneStackChanges {
+		destroyLeftScenes()
+	}
+
+	// Whatever happens in between frames, happens to the active Scene
+	sceneStack[len(sceneStack)-1].wrapper.displayed().activate()
+
+	Bus.deliver()
 }
 
 // RegisterScene registers the `Scene`, so it can later be used by `SetSceneByName`
 func RegisterScene(s Scene) {
 	_, ok := scenes[s.Type()]
 	if !ok {
-		scenes[s.Type()] = &sceneWrapper{scene: s}
+		scenes[s.Type()] = &sceneWrapper{scene: s, effect: noEffect}
 	}
 }
 
@@ -0,0 +1,280 @@
+package engi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+type countSystem struct {
+	*ecs.System
+	updates int
+}
+
+func (s *countSystem) New(*ecs.World) {
+	s.System = ecs.NewSystem()
+	s.AddEntity(ecs.NewEntity([]string{s.Type()}))
+}
+
+func (*countSystem) Type() string {
+	return "countSystem"
+}
+
+func (s *countSystem) Update(*ecs.Entity, float32) {
+	s.updates++
+}
+
+type stackScene struct {
+	name   string
+	events []string
+	count  *countSystem
+}
+
+func (*stackScene) Preload() {}
+
+func (s *stackScene) Setup(w *ecs.World) {
+	s.count = &countSystem{}
+	w.AddSystem(s.count)
+}
+
+func (s *stackScene) Show()        { s.events = append(s.events, "show") }
+func (s *stackScene) Hide()        { s.events = append(s.events, "hide") }
+func (s *stackScene) Type() string { return s.name }
+
+func resetScenes() {
+	headless = true
+	Time = NewClock()
+	Files = NewLoader()
+	scenes = make(map[string]*sceneWrapper)
+	sceneStack = nil
+	transitioning = nil
+	currentScene = nil
+	loadingScene = nil
+	leftScenes = nil
+}
+
+func TestPushPopScene(t *testing.T) {
+	resetScenes()
+
+	game := &stackScene{name: "game"}
+	pause := &stackScene{name: "pause"}
+
+	SetScene(game, false)
+	if err := PushScene(pause, UnderlayVisible, nil); err != nil {
+		t.Fatalf("pushing should succeed, not %v", err)
+	}
+	if err := PushScene(pause, UnderlayVisible, nil); err == nil {
+		t.Error("pushing a scene twice should fail")
+	}
+
+	if CurrentScene() != pause {
+		t.Errorf("current scene should be pause, not %v", CurrentScene())
+	}
+
+	updateScenes()
+	if game.count.updates != 0 {
+		t.Errorf("paused scene underneath should not be updated, was updated %d times", game.count.updates)
+	}
+	if pause.count.updates != 1 {
+		t.Errorf("top scene should be updated once, not %d times", pause.count.updates)
+	}
+
+	if err := PopScene(nil); err != nil {
+		t.Fatalf("popping should succeed, not %v", err)
+	}
+	if err := PopScene(nil); err == nil {
+		t.Error("popping the last scene should fail")
+	}
+
+	updateScenes()
+	if game.count.updates != 1 {
+		t.Errorf("scene should be updated after popping, was updated %d times", game.count.updates)
+	}
+
+	expected := []string{"show", "hide", "show"}
+	if len(game.events) != len(expected) {
+		t.Fatalf("game events should be %v, not %v", expected, game.events)
+	}
+	for i := range expected {
+		if game.events[i] != expected[i] {
+			t.Errorf("game events should be %v, not %v", expected, game.events)
+		}
+	}
+
+	if len(pause.events) != 2 || pause.events[0] != "show" || pause.events[1] != "hide" {
+		t.Errorf("pause events should be [show hide], not %v", pause.events)
+	}
+}
+
+func TestUnderlayUpdating(t *testing.T) {
+	resetScenes()
+
+	game := &stackScene{name: "game"}
+	hud := &stackScene{name: "hud"}
+
+	SetScene(game, false)
+	PushScene(hud, UnderlayUpdating|UnderlayVisible, nil)
+
+	updateScenes()
+	if game.count.updates != 1 || hud.count.updates != 1 {
+		t.Errorf("both scenes should be updated once, not %d and %d times", game.count.updates, hud.count.updates)
+	}
+}
+
+func TestTransition(t *testing.T) {
+	resetScenes()
+
+	first := &stackScene{name: "first"}
+	second := &stackScene{name: "second"}
+
+	SetScene(first, false)
+	SetSceneWithTransition(second, false, Crossfade(time.Second))
+
+	w := scenes["first"]
+	if w.effect.Alpha != 1 {
+		t.Errorf("leaving scene should start fully visible, not %v", w.effect.Alpha)
+	}
+
+	Time.setUnscaledDelta(0.5)
+	updateScenes()
+	if w.effect.Alpha != 0.5 {
+		t.Errorf("leaving scene should be half visible, not %v", w.effect.Alpha)
+	}
+	if w.world == nil {
+		t.Error("leaving scene should not be destroyed while it's rendered by the transition")
+	}
+	if first.count.updates != 0 {
+		t.Errorf("leaving scene should not be updated, was updated %d times", first.count.updates)
+	}
+
+	Time.setUnscaledDelta(0.5)
+	updateScenes()
+	if transitioning != nil {
+		t.Error("transition should be finished")
+	}
+	if w.world != nil {
+		t.Error("leaving scene should be destroyed once the transition has finished")
+	}
+	if scenes["second"].effect != noEffect {
+		t.Errorf("entering scene should be rendered as is, not %v", scenes["second"].effect)
+	}
+}
+
+type loadScene struct {
+	stackScene
+	urls   []string
+	errs   []*ResourceError
+	loaded bool
+}
+
+func (s *loadScene) Preload() {
+	Files.Add(s.urls...)
+}
+
+func (s *loadScene) Setup(w *ecs.World) {
+	s.stackScene.Setup(w)
+	s.loaded = true
+}
+
+func (s *loadScene) LoadErrors(errs []*ResourceError) {
+	s.errs = errs
+}
+
+func TestLoadingScene(t *testing.T) {
+	resetScenes()
+
+	dir, err := ioutil.TempDir("", "engi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := filepath.Join(dir, "data.json")
+	broken := filepath.Join(dir, "broken.png")
+	ioutil.WriteFile(data, []byte(`{"level": 1}`), 0644)
+	ioutil.WriteFile(broken, []byte("not a png"), 0644)
+
+	loading := &stackScene{name: "loading"}
+	game := &loadScene{stackScene: stackScene{name: "game"}, urls: []string{data, broken}}
+
+	SetLoadingScene(loading)
+	SetScene(game, false)
+
+	if CurrentScene() != loading {
+		t.Errorf("loading scene should be displayed while loading, not %v", CurrentScene())
+	}
+
+	for i := 0; i < 100 && !game.loaded; i++ {
+		time.Sleep(time.Millisecond)
+		updateScenes()
+	}
+
+	if !game.loaded {
+		t.Fatal("game should be set up once its resources are loaded")
+	}
+	if CurrentScene() != game {
+		t.Errorf("game should be displayed after loading, not %v", CurrentScene())
+	}
+	if Files.Json("data.json") != `{"level": 1}` {
+		t.Errorf("data.json should be loaded, not %q", Files.Json("data.json"))
+	}
+	if len(game.errs) != 1 || game.errs[0].Name != broken {
+		t.Errorf("broken.png should be reported as the only error, not %v", game.errs)
+	}
+	if p := Files.Loading().Progress(); p != 1 {
+		t.Errorf("progress should be 1, not %v", p)
+	}
+
+	if len(loading.events) != 2 || loading.events[0] != "show" || loading.events[1] != "hide" {
+		t.Errorf("loading events should be [show hide], not %v", loading.events)
+	}
+	if len(game.events) != 1 || game.events[0] != "show" {
+		t.Errorf("game events should be [show], not %v", game.events)
+	}
+}
+
+type persistentScene struct {
+	loadScene
+}
+
+func (*persistentScene) Persistent() bool { return true }
+
+func TestSceneAssetsReleasedWhenLeft(t *testing.T) {
+	resetScenes()
+
+	dir := writeFiles(t, map[string]string{"level1.json": `{}`, "level2.json": `{}`, "hud.json": `{}`, "menu.json": `{}`})
+	defer os.RemoveAll(dir)
+
+	level1 := &loadScene{stackScene: stackScene{name: "level1"}, urls: []string{
+		filepath.Join(dir, "level1.json"), filepath.Join(dir, "hud.json"),
+	}}
+	level2 := &loadScene{stackScene: stackScene{name: "level2"}, urls: []string{
+		filepath.Join(dir, "level2.json"), filepath.Join(dir, "hud.json"),
+	}}
+	menu := &persistentScene{loadScene{stackScene: stackScene{name: "menu"}, urls: []string{filepath.Join(dir, "menu.json")}}}
+
+	SetScene(menu, false)
+	SetScene(level1, false)
+	SetScene(level2, false)
+	if Files.Json("level1.json") != "" {
+		t.Error("the assets only the previous level used should be unloaded after switching")
+	}
+	if Files.Json("hud.json") == "" || Files.Json("level2.json") == "" {
+		t.Error("the assets of the current level should be loaded")
+	}
+	if Files.Json("menu.json") == "" {
+		t.Error("a persistent scene should keep its assets after leaving the stack")
+	}
+
+	SetScene(level1, false)
+	if Files.Json("level1.json") == "" || Files.Json("level2.json") != "" {
+		t.Error("returning to a scene should load its assets again")
+	}
+	if level1.count == nil {
+		t.Error("a scene which has been destroyed should be set up again")
+	}
+}
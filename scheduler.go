@@ -0,0 +1,120 @@
+package engi
+
+import (
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+// Timer is a handle to a callback which has been scheduled by the Scheduler
+type Timer struct {
+	fn        func()
+	remaining float32 // seconds until fn is called
+	interval  float32
+	repeat    bool
+	done      bool
+	entity    *ecs.Entity
+}
+
+// Cancel makes sure the callback will not be called (again)
+func (t *Timer) Cancel() {
+	t.done = true
+}
+
+// Active indicates whether or not the callback is still scheduled to be called
+func (t *Timer) Active() bool {
+	return !t.done
+}
+
+// Bind ties the Timer to the given Entity, so that it is cancelled whenever the Entity is removed from the World
+func (t *Timer) Bind(entity *ecs.Entity) *Timer {
+	t.entity = entity
+	return t
+}
+
+// Scheduler is a System which calls functions after a given amount of game time. Because it is driven by
+// the (scaled) time difference, timers do not advance while the game is paused. Callbacks are always called
+// on the game loop, in between the other Systems.
+type Scheduler struct {
+	*ecs.System
+
+	world   *ecs.World
+	timers  []*Timer
+	pending []*Timer
+}
+
+func (*Scheduler) Type() string {
+	return "Scheduler"
+}
+
+func (s *Scheduler) New(w *ecs.World) {
+	s.System = ecs.NewSystem()
+	s.world = w
+
+	s.AddEntity(ecs.NewEntity([]string{s.Type()}))
+}
+
+// After calls fn once, after d has passed
+func (s *Scheduler) After(d time.Duration, fn func()) *Timer {
+	return s.schedule(&Timer{fn: fn, remaining: float32(d.Seconds())})
+}
+
+// Every calls fn each time d has passed, until the Timer is cancelled
+func (s *Scheduler) Every(d time.Duration, fn func()) *Timer {
+	return s.schedule(&Timer{fn: fn, remaining: float32(d.Seconds()), interval: float32(d.Seconds()), repeat: true})
+}
+
+func (s *Scheduler) schedule(t *Timer) *Timer {
+	// Timers are added to the pending list, because they may be scheduled from within a callback
+	s.pending = append(s.pending, t)
+	return t
+}
+
+func (s *Scheduler) Update(entity *ecs.Entity, dt float32) {
+	s.timers = append(s.timers, s.pending...)
+	s.pending = s.pending[:0]
+
+	active := s.timers[:0]
+	for _, t := range s.timers {
+		if t.entity != nil && s.world != nil && !s.world.HasEntity(t.entity) {
+			t.done = true
+		}
+
+		if !t.done {
+			t.remaining -= dt
+		}
+
+		for t.remaining <= 0 && !t.done {
+			t.fn()
+
+			if !t.repeat {
+				t.done = true
+			} else if t.interval <= 0 {
+				t.remaining = 0
+				break // call it again next frame
+			} else {
+				t.remaining += t.interval
+			}
+		}
+
+		if !t.done {
+			active = append(active, t)
+		}
+	}
+
+	// Make sure the Timers we've dropped can be garbage collected
+	for i := len(active); i < len(s.timers); i++ {
+		s.timers[i] = nil
+	}
+	s.timers = active
+}
+
+// After calls fn once, after d has passed in the current Scene
+func After(d time.Duration, fn func()) *Timer {
+	return scheduler.After(d, fn)
+}
+
+// Every calls fn each time d has passed in the current Scene, until the Timer is cancelled
+func Every(d time.Duration, fn func()) *Timer {
+	return scheduler.Every(d, fn)
+}
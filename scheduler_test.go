@@ -0,0 +1,109 @@
+package engi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+func newTestScheduler() (*Scheduler, *ecs.World) {
+	w := &ecs.World{}
+	w.New()
+
+	s := &Scheduler{}
+	w.AddSystem(s)
+	return s, w
+}
+
+func TestSchedulerAfter(t *testing.T) {
+	s, w := newTestScheduler()
+
+	calls := 0
+	timer := s.After(time.Second, func() { calls++ })
+
+	w.Update(0.5)
+	if calls != 0 {
+		t.Errorf("callback should not have been called after 0.5 seconds, called %d times", calls)
+	}
+
+	w.Update(0.5)
+	if calls != 1 {
+		t.Errorf("callback should have been called once after 1 second, called %d times", calls)
+	}
+
+	w.Update(1)
+	if calls != 1 {
+		t.Errorf("callback should only be called once, called %d times", calls)
+	}
+
+	if timer.Active() {
+		t.Error("timer should not be active after it has been called")
+	}
+}
+
+func TestSchedulerEvery(t *testing.T) {
+	s, w := newTestScheduler()
+
+	calls := 0
+	timer := s.Every(time.Second, func() { calls++ })
+
+	w.Update(1)
+	w.Update(2)
+	if calls != 3 {
+		t.Errorf("callback should have been called 3 times, called %d times", calls)
+	}
+
+	timer.Cancel()
+	w.Update(1)
+	if calls != 3 {
+		t.Errorf("callback should not be called after cancelling, called %d times", calls)
+	}
+}
+
+func TestSchedulerPaused(t *testing.T) {
+	s, w := newTestScheduler()
+
+	calls := 0
+	s.After(time.Second, func() { calls++ })
+
+	w.UpdateScaled(0, 5)
+	if calls != 0 {
+		t.Errorf("callback should not be called while paused, called %d times", calls)
+	}
+}
+
+func TestSchedulerBind(t *testing.T) {
+	s, w := newTestScheduler()
+
+	e := ecs.NewEntity(nil)
+	w.AddEntity(e)
+
+	calls := 0
+	timer := s.After(time.Second, func() { calls++ }).Bind(e)
+
+	w.RemoveEntity(e)
+	w.Update(1)
+	if calls != 0 {
+		t.Errorf("callback should not be called after its entity was removed, called %d times", calls)
+	}
+
+	if timer.Active() {
+		t.Error("timer should not be active after its entity was removed")
+	}
+}
+
+func TestSchedulerNested(t *testing.T) {
+	s, w := newTestScheduler()
+
+	calls := 0
+	s.After(time.Second, func() {
+		s.After(time.Second, func() { calls++ })
+	})
+
+	w.Update(1)
+	w.Update(1)
+	if calls != 1 {
+		t.Errorf("nested callback should have been called once, called %d times", calls)
+	}
+}
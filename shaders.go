@@ -22,6 +22,7 @@ type DefaultShader struct {
 	projY float32
 
 	lastTexture *webgl.Texture
+	lastBuffer  *webgl.Buffer
 
 	inPosition   int
 	inTexCoords  int
@@ -29,6 +30,8 @@ type DefaultShader struct {
 	ufCamera     *webgl.UniformLocation
 	ufPosition   *webgl.UniformLocation
 	ufProjection *webgl.UniformLocation
+	ufOffset     *webgl.UniformLocation
+	ufAlpha      *webgl.UniformLocation
 }
 
 func (s *DefaultShader) Initialize(width, height float32) {
@@ -42,6 +45,7 @@ attribute vec4 in_Color;
 uniform vec2 uf_Position;
 uniform vec3 uf_Camera;
 uniform vec2 uf_Projection;
+uniform vec2 uf_Offset;
 
 varying vec4 var_Color;
 varying vec2 var_TexCoords;
@@ -53,7 +57,7 @@ void main() {
   gl_Position = vec4((in_Position.x + uf_Position.x - uf_Camera.x)/  uf_Projection.x,
   					 (in_Position.y + uf_Position.y - uf_Camera.y)/ -uf_Projection.y,
   					 0.0, uf_Camera.z);
-
+  gl_Position.xy += vec2(uf_Offset.x / uf_Projection.x, uf_Offset.y / -uf_Projection.y) * uf_Camera.z;
 }`, `
 /* Fragment Shader */
 #ifdef GL_ES
@@ -67,9 +71,10 @@ varying vec4 var_Color;
 varying vec2 var_TexCoords;
 
 uniform sampler2D uf_Texture;
+uniform float uf_Alpha;
 
 void main (void) {
-  gl_FragColor = var_Color * texture2D(uf_Texture, var_TexCoords);
+  gl_FragColor = var_Color * texture2D(uf_Texture, var_TexCoords) * vec4(1.0, 1.0, 1.0, uf_Alpha);
 }`)
 
 	// Create and populate indices buffer
@@ -97,6 +102,8 @@ void main (void) {
 	s.ufCamera = Gl.GetUniformLocation(s.program, "uf_Camera")
 	s.ufPosition = Gl.GetUniformLocation(s.program, "uf_Position")
 	s.ufProjection = Gl.GetUniformLocation(s.program, "uf_Projection")
+	s.ufOffset = Gl.GetUniformLocation(s.program, "uf_Offset")
+	s.ufAlpha = Gl.GetUniformLocation(s.program, "uf_Alpha")
 
 	// Enable those things
 	Gl.EnableVertexAttribArray(s.inPosition)
@@ -110,19 +117,26 @@ void main (void) {
 func (s *DefaultShader) Pre() {
 	Gl.UseProgram(s.program)
 	Gl.Uniform2f(s.ufProjection, s.projX, s.projY)
+	Gl.Uniform2f(s.ufOffset, sceneEffect.Offset.X, sceneEffect.Offset.Y)
+	Gl.Uniform1f(s.ufAlpha, sceneEffect.Alpha)
 	Gl.Uniform3f(s.ufCamera, cam.x, cam.y, cam.z)
 }
 
 func (s *DefaultShader) Draw(texture *webgl.Texture, buffer *webgl.Buffer, x, y, rotation float32) {
 	if s.lastTexture != texture {
 		Gl.BindTexture(Gl.TEXTURE_2D, texture)
+		s.lastTexture = texture
+	}
+
+	// Entities drawn from the same texture (i.e. an atlas) still have buffers of their own
+	if s.lastBuffer != buffer {
 		Gl.BindBuffer(Gl.ARRAY_BUFFER, buffer)
 
 		Gl.VertexAttribPointer(s.inPosition, 2, Gl.FLOAT, false, 20, 0)
 		Gl.VertexAttribPointer(s.inTexCoords, 2, Gl.FLOAT, false, 20, 8)
 		Gl.VertexAttribPointer(s.inColor, 4, Gl.UNSIGNED_BYTE, true, 20, 16)
 
-		s.lastTexture = texture
+		s.lastBuffer = buffer
 	}
 
 	// TODO: add rotation
@@ -132,6 +146,7 @@ func (s *DefaultShader) Draw(texture *webgl.Texture, buffer *webgl.Buffer, x, y,
 
 func (s *DefaultShader) Post() {
 	s.lastTexture = nil
+	s.lastBuffer = nil
 }
 
 func (s *DefaultShader) SetProjection(width, height float32) {
@@ -148,12 +163,15 @@ type HUDShader struct {
 	projY float32
 
 	lastTexture *webgl.Texture
+	lastBuffer  *webgl.Buffer
 
 	inPosition   int
 	inTexCoords  int
 	inColor      int
 	ufPosition   *webgl.UniformLocation
 	ufProjection *webgl.UniformLocation
+	ufOffset     *webgl.UniformLocation
+	ufAlpha      *webgl.UniformLocation
 }
 
 func (s *HUDShader) Initialize(width, height float32) {
@@ -166,6 +184,7 @@ attribute vec4 in_Color;
 
 uniform vec2 uf_Position;
 uniform vec2 uf_Projection;
+uniform vec2 uf_Offset;
 
 varying vec4 var_Color;
 varying vec2 var_TexCoords;
@@ -174,8 +193,8 @@ void main() {
   var_Color = in_Color;
   var_TexCoords = in_TexCoords;
 
-  gl_Position = vec4((in_Position.x + uf_Position.x)/  uf_Projection.x - 1.0,
-  					 (in_Position.y + uf_Position.y)/ -uf_Projection.y + 1.0,
+  gl_Position = vec4((in_Position.x + uf_Position.x + uf_Offset.x)/  uf_Projection.x - 1.0,
+  					 (in_Position.y + uf_Position.y + uf_Offset.y)/ -uf_Projection.y + 1.0,
   					 0.0, 1.0);
 
 }`, `
@@ -190,9 +209,10 @@ varying vec4 var_Color;
 varying vec2 var_TexCoords;
 
 uniform sampler2D uf_Texture;
+uniform float uf_Alpha;
 
 void main (void) {
-  gl_FragColor = var_Color * texture2D(uf_Texture, var_TexCoords);
+  gl_FragColor = var_Color * texture2D(uf_Texture, var_TexCoords) * vec4(1.0, 1.0, 1.0, uf_Alpha);
 }`)
 
 	// Create and populate indices buffer
@@ -219,6 +239,8 @@ void main (void) {
 	// Define things that should be set per draw
 	s.ufPosition = Gl.GetUniformLocation(s.program, "uf_Position")
 	s.ufProjection = Gl.GetUniformLocation(s.program, "uf_Projection")
+	s.ufOffset = Gl.GetUniformLocation(s.program, "uf_Offset")
+	s.ufAlpha = Gl.GetUniformLocation(s.program, "uf_Alpha")
 
 	// Enable those things
 	Gl.EnableVertexAttribArray(s.inPosition)
@@ -234,18 +256,25 @@ void main (void) {
 func (s *HUDShader) Pre() {
 	Gl.UseProgram(s.program)
 	Gl.Uniform2f(s.ufProjection, s.projX, s.projY)
+	Gl.Uniform2f(s.ufOffset, sceneEffect.Offset.X, sceneEffect.Offset.Y)
+	Gl.Uniform1f(s.ufAlpha, sceneEffect.Alpha)
 }
 
 func (s *HUDShader) Draw(texture *webgl.Texture, buffer *webgl.Buffer, x, y, rotation float32) {
 	if s.lastTexture != texture {
 		Gl.BindTexture(Gl.TEXTURE_2D, texture)
+		s.lastTexture = texture
+	}
+
+	// Entities drawn from the same texture (i.e. an atlas) still have buffers of their own
+	if s.lastBuffer != buffer {
 		Gl.BindBuffer(Gl.ARRAY_BUFFER, buffer)
 
 		Gl.VertexAttribPointer(s.inPosition, 2, Gl.FLOAT, false, 20, 0)
 		Gl.VertexAttribPointer(s.inTexCoords, 2, Gl.FLOAT, false, 20, 8)
 		Gl.VertexAttribPointer(s.inColor, 4, Gl.UNSIGNED_BYTE, true, 20, 16)
 
-		s.lastTexture = texture
+		s.lastBuffer = buffer
 	}
 
 	Gl.Uniform2f(s.ufPosition, x, y)
@@ -254,6 +283,7 @@ func (s *HUDShader) Draw(texture *webgl.Texture, buffer *webgl.Buffer, x, y, rot
 
 func (s *HUDShader) Post() {
 	s.lastTexture = nil
+	s.lastBuffer = nil
 }
 
 func (s *HUDShader) SetProjection(width, height float32) {
@@ -0,0 +1,580 @@
+package engi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/paked/webgl"
+)
+
+// maxSVGSize is the maximum width and height SVGs are rasterised at, which limits how far they can be scaled up
+const maxSVGSize = 4096
+
+// SVG is a vector image, which is rasterised to a Texture. It is a Drawable of its own size, which is rasterised
+// again at a higher resolution when a RenderComponent scales it up, so it stays crisp.
+//
+// Only a subset of SVG is supported: paths, rect, circle, ellipse, line, polyline and polygon elements, which
+// can be grouped, with solid fills and strokes, opacity and transforms. Fills always use the nonzero rule,
+// strokes have round joins, and everything else, like text, gradients and dashes, is skipped.
+type SVG struct {
+	width, height float32
+	shapes        []svgShape
+
+	texture *Texture
+	// scale is the resolution the texture has been rasterised at, relative to the size of the SVG
+	scale float32
+}
+
+// Width returns the width of the SVG, in pixels at a scale of 1
+func (s *SVG) Width() float32 {
+	return s.width
+}
+
+// Height returns the height of the SVG, in pixels at a scale of 1
+func (s *SVG) Height() float32 {
+	return s.height
+}
+
+func (s *SVG) Texture() *webgl.Texture {
+	return s.texture.id
+}
+
+func (s *SVG) View() (float32, float32, float32, float32) {
+	return s.texture.View()
+}
+
+// Rasterized returns the Texture the SVG has been rasterised to
+func (s *SVG) Rasterized() *Texture {
+	return s.texture
+}
+
+// Scale returns the resolution the SVG has been rasterised at, relative to its own size
+func (s *SVG) Scale() float32 {
+	return s.scale
+}
+
+// Rasterize rasterises the SVG again, at the scale relative to its own size, into the same Texture
+func (s *SVG) Rasterize(scale float32) {
+	scale = s.clampScale(scale)
+	if scale == s.scale && s.texture != nil {
+		return
+	}
+	s.scale = scale
+
+	pixels := s.rasterize(scale)
+	if s.texture == nil {
+		s.texture = NewTexture(rgbaImage{pixels})
+		s.texture.source = s.pixels
+	} else {
+		s.texture.upload(rgbaImage{pixels})
+	}
+	logDebug(LogAssets, "rasterized svg", "scale", scale, "width", pixels.Rect.Dx(), "height", pixels.Rect.Dy())
+}
+
+// drawnAt makes sure the SVG is rasterised at a resolution which is high enough to be drawn at the scale; it
+// never lowers the resolution, since the SVG may be drawn at a higher scale elsewhere. The resolution is rounded
+// up to a power of two, so an SVG which is scaled up gradually, i.e. by a Tween, isn't rasterised every frame.
+func (s *SVG) drawnAt(scale Point) {
+	wanted := math.Max(math.Abs(float64(scale.X)), math.Abs(float64(scale.Y)))
+	if float32(wanted) <= s.scale {
+		return
+	}
+	if quantised := s.clampScale(float32(math.Exp2(math.Ceil(math.Log2(wanted))))); quantised > s.scale {
+		s.Rasterize(quantised)
+	}
+}
+
+// pixels rasterises the SVG again at its current resolution, which is the source of its texture
+func (s *SVG) pixels() (Image, error) {
+	return rgbaImage{s.rasterize(s.scale)}, nil
+}
+
+func (s *SVG) clampScale(scale float32) float32 {
+	if scale <= 0 {
+		scale = 1
+	}
+	if max := maxSVGSize / float32(math.Max(float64(s.width), float64(s.height))); scale > max {
+		return max
+	}
+	return scale
+}
+
+// SVG returns the SVG with the given name, or nil
+func (l *Loader) SVG(name string) *SVG {
+	svg, _ := getAsset[*SVG](l, "svg", name)
+	return svg
+}
+
+// decodedSVG is an SVG that has been parsed and rasterised in the background, and still has to be uploaded
+type decodedSVG struct {
+	svg    *SVG
+	pixels *image.RGBA
+}
+
+// decodeSVG parses the SVG, and rasterises it at the scale of its settings
+func decodeSVG(r Resource) (interface{}, error) {
+	file, err := r.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	svg, err := parseSVG(file)
+	if err != nil {
+		return nil, err
+	}
+	svg.scale = svg.clampScale(float32(r.settings.Scale))
+	return &decodedSVG{svg, svg.rasterize(svg.scale)}, nil
+}
+
+func finishSVG(r Resource, data interface{}) (interface{}, error) {
+	d := data.(*decodedSVG)
+	tex, err := finishImage(r, rgbaImage{d.pixels})
+	if err != nil {
+		return nil, err
+	}
+	d.svg.texture = tex.(*Texture)
+	d.svg.texture.source = d.svg.pixels
+	return d.svg, nil
+}
+
+// reloadSVG replaces the shapes of the SVG, keeping the resolution it's drawn at
+func reloadSVG(asset interface{}, r Resource, data interface{}) error {
+	svg, d := asset.(*SVG), data.(*decodedSVG)
+	svg.width, svg.height, svg.shapes = d.svg.width, d.svg.height, d.svg.shapes
+
+	if svg.clampScale(svg.scale) == d.svg.scale {
+		svg.texture.upload(rgbaImage{d.pixels})
+		return nil
+	}
+	scale := svg.scale
+	svg.scale = 0
+	svg.Rasterize(scale)
+	return nil
+}
+
+func unloadSVG(asset interface{}) {
+	asset.(*SVG).texture.Delete()
+}
+
+func svgSize(asset interface{}) int {
+	return imageSize(asset.(*SVG).texture)
+}
+
+// svgShape is a path with the way it's painted, in the coordinates of the SVG after all transforms
+type svgShape struct {
+	path []svgSegment
+	// fill and stroke are premultiplied by alpha, and transparent if the shape isn't filled or stroked
+	fill, stroke color.RGBA
+	strokeWidth  float64
+	lineCap      string
+}
+
+// svgStyle is the presentation of an element, which its children inherit
+type svgStyle struct {
+	fill, stroke                        color.NRGBA
+	fillOpacity, strokeOpacity, opacity float64
+	strokeWidth                         float64
+	lineCap                             string
+	transform                           svgMatrix
+	// color is the color currentColor refers to
+	color color.NRGBA
+}
+
+// parseSVG reads the elements of the SVG that are supported, and skips all others
+func parseSVG(r io.Reader) (*SVG, error) {
+	dec := xml.NewDecoder(r)
+	svg := &SVG{}
+	var styles []svgStyle
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse svg: %v", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			attrs := svgAttrs(el)
+
+			if len(styles) == 0 {
+				if el.Name.Local != "svg" {
+					return nil, fmt.Errorf("could not parse svg: root element is %s", el.Name.Local)
+				}
+				viewport, err := svg.viewport(attrs)
+				if err != nil {
+					return nil, err
+				}
+				style := svgStyle{fill: color.NRGBA{0, 0, 0, 255}, fillOpacity: 1, strokeOpacity: 1, opacity: 1, strokeWidth: 1,
+					lineCap: "butt", transform: viewport, color: color.NRGBA{0, 0, 0, 255}}
+				if styles, err = appendStyle(styles, style, attrs); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			switch el.Name.Local {
+			case "g", "a", "svg":
+				if styles, err = appendStyle(styles, styles[len(styles)-1], attrs); err != nil {
+					return nil, err
+				}
+				continue
+			case "path", "rect", "circle", "ellipse", "line", "polyline", "polygon":
+				style, err := styles[len(styles)-1].inherit(attrs)
+				if err != nil {
+					return nil, err
+				}
+				path, err := svgElementPath(el.Name.Local, attrs)
+				if err != nil {
+					return nil, fmt.Errorf("could not parse svg %s: %v", el.Name.Local, err)
+				}
+				if shape, ok := style.shape(path); ok {
+					svg.shapes = append(svg.shapes, shape)
+				}
+			}
+
+			// Shapes have no children that are drawn, and other elements aren't supported
+			if err := dec.Skip(); err != nil {
+				return nil, fmt.Errorf("could not parse svg: %v", err)
+			}
+		case xml.EndElement:
+			styles = styles[:len(styles)-1]
+		}
+	}
+
+	if svg.width <= 0 || svg.height <= 0 {
+		return nil, fmt.Errorf("could not parse svg: it has no svg element with a size")
+	}
+	return svg, nil
+}
+
+func appendStyle(styles []svgStyle, parent svgStyle, attrs map[string]string) ([]svgStyle, error) {
+	style, err := parent.inherit(attrs)
+	if err != nil {
+		return nil, err
+	}
+	return append(styles, style), nil
+}
+
+// svgAttrs returns the attributes of the element, including the declarations of its style attribute, which
+// take precedence
+func svgAttrs(el xml.StartElement) map[string]string {
+	attrs := make(map[string]string)
+	for _, attr := range el.Attr {
+		attrs[attr.Name.Local] = strings.TrimSpace(attr.Value)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if i := strings.Index(decl, ":"); i >= 0 {
+			attrs[strings.TrimSpace(decl[:i])] = strings.TrimSpace(decl[i+1:])
+		}
+	}
+	return attrs
+}
+
+// viewport sets the size of the SVG from the root element, and returns the transform of its viewBox
+func (s *SVG) viewport(attrs map[string]string) (svgMatrix, error) {
+	var box []float64
+	if attrs["viewBox"] != "" {
+		var err error
+		if box, err = svgNumbers(attrs["viewBox"]); err != nil || len(box) != 4 || box[2] <= 0 || box[3] <= 0 {
+			return svgMatrix{}, fmt.Errorf("could not parse svg: invalid viewBox %q", attrs["viewBox"])
+		}
+	}
+
+	width, height := svgLength(attrs["width"]), svgLength(attrs["height"])
+	if box != nil {
+		if width <= 0 && height <= 0 {
+			width, height = box[2], box[3]
+		} else if width <= 0 {
+			width = height * box[2] / box[3]
+		} else if height <= 0 {
+			height = width * box[3] / box[2]
+		}
+	}
+	s.width, s.height = float32(width), float32(height)
+	if box == nil {
+		return identity(), nil
+	}
+
+	// The viewBox is scaled to fit, and centered, like preserveAspectRatio="xMidYMid meet"
+	scale := math.Min(width/box[2], height/box[3])
+	if attrs["preserveAspectRatio"] == "none" {
+		return svgMatrix{width / box[2], 0, 0, height / box[3], -box[0] * width / box[2], -box[1] * height / box[3]}, nil
+	}
+	return svgMatrix{scale, 0, 0, scale, (width-box[2]*scale)/2 - box[0]*scale, (height-box[3]*scale)/2 - box[1]*scale}, nil
+}
+
+// svgLength parses a length in pixels, or returns 0 for lengths relative to something else
+func svgLength(s string) float64 {
+	s = strings.TrimSuffix(s, "px")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// inherit returns the style of an element with the attributes, and this style as the style of its parent
+func (s svgStyle) inherit(attrs map[string]string) (svgStyle, error) {
+	// The color has to be known before the paints which may refer to it
+	if value, ok := attrs["color"]; ok && value != "inherit" {
+		s.color = svgPaint(value, s.color)
+	}
+
+	var err error
+	for name, value := range attrs {
+		if value == "inherit" {
+			continue
+		}
+		switch name {
+		case "fill":
+			s.fill = svgPaint(value, s.color)
+		case "stroke":
+			s.stroke = svgPaint(value, s.color)
+		case "fill-opacity":
+			s.fillOpacity, err = strconv.ParseFloat(value, 64)
+		case "stroke-opacity":
+			s.strokeOpacity, err = strconv.ParseFloat(value, 64)
+		case "opacity":
+			// Opacity applies to the element as a whole, so it accumulates rather than being inherited
+			var opacity float64
+			opacity, err = strconv.ParseFloat(value, 64)
+			s.opacity *= opacity
+		case "stroke-width":
+			s.strokeWidth = svgLength(value)
+		case "stroke-linecap":
+			s.lineCap = value
+		case "transform":
+			var m svgMatrix
+			m, err = svgTransform(value)
+			s.transform = s.transform.mul(m)
+		}
+		if err != nil {
+			return s, fmt.Errorf("could not parse svg attribute %s=%q: %v", name, value, err)
+		}
+	}
+	return s, nil
+}
+
+// shape transforms the path, and determines how it is painted; it returns false if it isn't painted at all
+func (s svgStyle) shape(path []svgSegment) (svgShape, bool) {
+	shape := svgShape{
+		path:        make([]svgSegment, len(path)),
+		fill:        premultiply(s.fill, s.fillOpacity*s.opacity),
+		stroke:      premultiply(s.stroke, s.strokeOpacity*s.opacity),
+		strokeWidth: s.strokeWidth * s.transform.scale(),
+		lineCap:     s.lineCap,
+	}
+	if shape.strokeWidth <= 0 {
+		shape.stroke = color.RGBA{}
+	}
+	for i, seg := range path {
+		for j := range seg.pts {
+			seg.pts[j] = s.transform.apply(seg.pts[j])
+		}
+		shape.path[i] = seg
+	}
+	return shape, len(path) > 0 && (shape.fill.A > 0 || shape.stroke.A > 0)
+}
+
+func premultiply(c color.NRGBA, opacity float64) color.RGBA {
+	a := float64(c.A) * math.Max(0, math.Min(1, opacity)) / 255
+	return color.RGBA{uint8(float64(c.R)*a + 0.5), uint8(float64(c.G)*a + 0.5), uint8(float64(c.B)*a + 0.5), uint8(a*255 + 0.5)}
+}
+
+// svgColors are the named colors which are supported
+var svgColors = map[string]color.NRGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"lime":    {0, 255, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"aqua":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"fuchsia": {255, 0, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"silver":  {192, 192, 192, 255},
+	"maroon":  {128, 0, 0, 255},
+	"olive":   {128, 128, 0, 255},
+	"navy":    {0, 0, 128, 255},
+	"purple":  {128, 0, 128, 255},
+	"teal":    {0, 128, 128, 255},
+	"orange":  {255, 165, 0, 255},
+}
+
+// svgPaint parses a fill or stroke, where currentColor is the current color. Paints which aren't a color, like
+// gradients, and colors which aren't supported, are skipped as if they were none, rather than failing the SVG.
+func svgPaint(s string, current color.NRGBA) color.NRGBA {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "none":
+		return color.NRGBA{}
+	case "currentcolor":
+		return current
+	}
+
+	c, err := svgColor(s)
+	if err != nil {
+		logWarn(LogAssets, "unsupported svg paint", "paint", s, "err", err)
+		return color.NRGBA{}
+	}
+	return c
+}
+
+// svgColor parses a color, which is either named, hexadecimal, or rgb() or rgba() with an optional alpha
+func svgColor(s string) (color.NRGBA, error) {
+	switch {
+	case s == "transparent":
+		return color.NRGBA{}, nil
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			long := make([]byte, 0, 8)
+			for i := range hex {
+				long = append(long, hex[i], hex[i])
+			}
+			hex = string(long)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 8 {
+			return color.NRGBA{}, fmt.Errorf("invalid color")
+		}
+		return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+	case (strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba(")) && strings.HasSuffix(s, ")"):
+		parts := strings.Split(s[strings.Index(s, "(")+1:len(s)-1], ",")
+		if len(parts) != 3 && len(parts) != 4 {
+			return color.NRGBA{}, fmt.Errorf("invalid color")
+		}
+		rgba := [4]uint8{3: 255}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			// The channels range from 0 to 255, and the alpha from 0 to 1, unless they are percentages
+			scale := 1.0
+			if i == 3 {
+				scale = 255
+			}
+			if strings.HasSuffix(part, "%") {
+				part, scale = strings.TrimSuffix(part, "%"), 2.55
+			}
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return color.NRGBA{}, fmt.Errorf("invalid color")
+			}
+			rgba[i] = uint8(math.Max(0, math.Min(255, v*scale+0.5)))
+		}
+		return color.NRGBA{rgba[0], rgba[1], rgba[2], rgba[3]}, nil
+	}
+
+	c, ok := svgColors[s]
+	if !ok {
+		return color.NRGBA{}, fmt.Errorf("unknown color")
+	}
+	return c, nil
+}
+
+// svgMatrix is an affine transform a, b, c, d, e, f, like the matrix() transform
+type svgMatrix [6]float64
+
+func identity() svgMatrix {
+	return svgMatrix{1, 0, 0, 1, 0, 0}
+}
+
+// mul returns the transform which applies n, then m
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(p svgPoint) svgPoint {
+	return svgPoint{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+// scale is the factor by which the transform scales lengths on average, which is used for stroke widths
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// svgTransform parses a list of transforms, which are applied right to left
+func svgTransform(s string) (svgMatrix, error) {
+	m := identity()
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " \t\r\n,") {
+		open, close := strings.Index(s, "("), strings.Index(s, ")")
+		if open < 0 || close < open {
+			return m, fmt.Errorf("invalid transform")
+		}
+		name := strings.TrimSpace(s[:open])
+		args, err := svgNumbers(s[open+1 : close])
+		if err != nil {
+			return m, err
+		}
+		s = s[close+1:]
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t svgMatrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return m, fmt.Errorf("matrix needs 6 numbers")
+			}
+			copy(t[:], args)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			t = svgMatrix{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			rad := arg(0, 0) * math.Pi / 180
+			cos, sin := math.Cos(rad), math.Sin(rad)
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.mul(svgMatrix{cos, sin, -sin, cos, 0, 0}).mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("unknown transform %s", name)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
+
+// svgNumbers parses a list of numbers, separated by whitespace and/or commas
+func svgNumbers(s string) ([]float64, error) {
+	sc := svgScanner{s: s}
+	var numbers []float64
+	for sc.skip(); !sc.done(); sc.skip() {
+		v, err := sc.number()
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, v)
+	}
+	return numbers, nil
+}
@@ -0,0 +1,608 @@
+package engi
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/vector"
+)
+
+type svgPoint struct {
+	x, y float64
+}
+
+// svgSegment is a segment of a path; pts holds 1 point for 'M' and 'L', 2 for 'Q' and 3 for 'C', and none
+// for 'Z', which closes the subpath
+type svgSegment struct {
+	op  byte
+	pts [3]svgPoint
+}
+
+// svgKappa is the distance of the control points of a cubic curve that approximates a quarter of a circle
+const svgKappa = 0.5522847498
+
+// svgElementPath returns the path of a shape element
+func svgElementPath(name string, attrs map[string]string) ([]svgSegment, error) {
+	num := func(attr string) float64 {
+		return svgLength(attrs[attr])
+	}
+
+	switch name {
+	case "path":
+		return svgPathData(attrs["d"])
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		rx, ry := num("rx"), num("ry")
+		if rx <= 0 {
+			rx = ry
+		}
+		if ry <= 0 {
+			ry = rx
+		}
+		return svgRect(x, y, w, h, math.Min(rx, w/2), math.Min(ry, h/2)), nil
+	case "circle":
+		return svgEllipse(num("cx"), num("cy"), num("r"), num("r")), nil
+	case "ellipse":
+		return svgEllipse(num("cx"), num("cy"), num("rx"), num("ry")), nil
+	case "line":
+		return []svgSegment{
+			{op: 'M', pts: [3]svgPoint{{num("x1"), num("y1")}}},
+			{op: 'L', pts: [3]svgPoint{{num("x2"), num("y2")}}},
+		}, nil
+	case "polyline", "polygon":
+		numbers, err := svgNumbers(attrs["points"])
+		if err != nil {
+			return nil, err
+		}
+		var path []svgSegment
+		for i := 0; i+1 < len(numbers); i += 2 {
+			op := byte('L')
+			if i == 0 {
+				op = 'M'
+			}
+			path = append(path, svgSegment{op: op, pts: [3]svgPoint{{numbers[i], numbers[i+1]}}})
+		}
+		if name == "polygon" && len(path) > 0 {
+			path = append(path, svgSegment{op: 'Z'})
+		}
+		return path, nil
+	}
+	return nil, fmt.Errorf("unsupported element")
+}
+
+// svgRect returns the path of a rectangle, with corners rounded by rx and ry
+func svgRect(x, y, w, h, rx, ry float64) []svgSegment {
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	if rx <= 0 || ry <= 0 {
+		return []svgSegment{
+			{op: 'M', pts: [3]svgPoint{{x, y}}},
+			{op: 'L', pts: [3]svgPoint{{x + w, y}}},
+			{op: 'L', pts: [3]svgPoint{{x + w, y + h}}},
+			{op: 'L', pts: [3]svgPoint{{x, y + h}}},
+			{op: 'Z'},
+		}
+	}
+
+	kx, ky := rx*svgKappa, ry*svgKappa
+	r, b := x+w, y+h
+	return []svgSegment{
+		{op: 'M', pts: [3]svgPoint{{x + rx, y}}},
+		{op: 'L', pts: [3]svgPoint{{r - rx, y}}},
+		{op: 'C', pts: [3]svgPoint{{r - rx + kx, y}, {r, y + ry - ky}, {r, y + ry}}},
+		{op: 'L', pts: [3]svgPoint{{r, b - ry}}},
+		{op: 'C', pts: [3]svgPoint{{r, b - ry + ky}, {r - rx + kx, b}, {r - rx, b}}},
+		{op: 'L', pts: [3]svgPoint{{x + rx, b}}},
+		{op: 'C', pts: [3]svgPoint{{x + rx - kx, b}, {x, b - ry + ky}, {x, b - ry}}},
+		{op: 'L', pts: [3]svgPoint{{x, y + ry}}},
+		{op: 'C', pts: [3]svgPoint{{x, y + ry - ky}, {x + rx - kx, y}, {x + rx, y}}},
+		{op: 'Z'},
+	}
+}
+
+// svgEllipse returns the path of an ellipse, as four cubic curves
+func svgEllipse(cx, cy, rx, ry float64) []svgSegment {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	kx, ky := rx*svgKappa, ry*svgKappa
+	return []svgSegment{
+		{op: 'M', pts: [3]svgPoint{{cx + rx, cy}}},
+		{op: 'C', pts: [3]svgPoint{{cx + rx, cy + ky}, {cx + kx, cy + ry}, {cx, cy + ry}}},
+		{op: 'C', pts: [3]svgPoint{{cx - kx, cy + ry}, {cx - rx, cy + ky}, {cx - rx, cy}}},
+		{op: 'C', pts: [3]svgPoint{{cx - rx, cy - ky}, {cx - kx, cy - ry}, {cx, cy - ry}}},
+		{op: 'C', pts: [3]svgPoint{{cx + kx, cy - ry}, {cx + rx, cy - ky}, {cx + rx, cy}}},
+		{op: 'Z'},
+	}
+}
+
+// svgScanner reads the numbers and commands of path data
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) done() bool {
+	return sc.i >= len(sc.s)
+}
+
+// skip skips whitespace and a comma
+func (sc *svgScanner) skip() {
+	comma := false
+	for !sc.done() {
+		switch c := sc.s[sc.i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c == ',' && !comma:
+			comma = true
+		default:
+			return
+		}
+		sc.i++
+	}
+}
+
+// isNumber returns whether a number is next, rather than a command
+func (sc *svgScanner) isNumber() bool {
+	sc.skip()
+	if sc.done() {
+		return false
+	}
+	c := sc.s[sc.i]
+	return c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9'
+}
+
+// number reads a number, which may directly follow the previous one, as in "1.5.5" or "1-2"
+func (sc *svgScanner) number() (float64, error) {
+	sc.skip()
+	start := sc.i
+	if !sc.done() && (sc.s[sc.i] == '-' || sc.s[sc.i] == '+') {
+		sc.i++
+	}
+	dot, exp := false, false
+	for ; !sc.done(); sc.i++ {
+		c := sc.s[sc.i]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		if c == '.' && !dot && !exp {
+			dot = true
+			continue
+		}
+		if (c == 'e' || c == 'E') && !exp && sc.i > start {
+			exp = true
+			if sc.i+1 < len(sc.s) && (sc.s[sc.i+1] == '-' || sc.s[sc.i+1] == '+') {
+				sc.i++
+			}
+			continue
+		}
+		break
+	}
+	v, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number at %d", start)
+	}
+	return v, nil
+}
+
+// flag reads a flag of an arc, which is a single digit that may be followed by the next number directly
+func (sc *svgScanner) flag() (bool, error) {
+	sc.skip()
+	if sc.done() || sc.s[sc.i] != '0' && sc.s[sc.i] != '1' {
+		return false, fmt.Errorf("invalid flag at %d", sc.i)
+	}
+	sc.i++
+	return sc.s[sc.i-1] == '1', nil
+}
+
+// svgPathData parses the d attribute of a path into absolute segments, with arcs turned into cubic curves
+func svgPathData(d string) ([]svgSegment, error) {
+	sc := svgScanner{s: d}
+	var path []svgSegment
+	var cur, start, ctrl svgPoint
+	var cmd, prev byte
+
+	for sc.skip(); !sc.done(); sc.skip() {
+		if !sc.isNumber() {
+			cmd = sc.s[sc.i]
+			sc.i++
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("path data starts with a number")
+		}
+
+		rel := cmd >= 'a'
+		pt := func() (svgPoint, error) {
+			x, err := sc.number()
+			if err != nil {
+				return svgPoint{}, err
+			}
+			y, err := sc.number()
+			if rel {
+				x, y = x+cur.x, y+cur.y
+			}
+			return svgPoint{x, y}, err
+		}
+		// reflect returns the reflection of the previous control point, if the previous segment was of the kind
+		reflect := func(kinds string) svgPoint {
+			for i := range kinds {
+				if prev == kinds[i] {
+					return svgPoint{2*cur.x - ctrl.x, 2*cur.y - ctrl.y}
+				}
+			}
+			return cur
+		}
+
+		var err error
+		upper := cmd &^ 0x20
+		switch upper {
+		case 'M':
+			var p svgPoint
+			if p, err = pt(); err == nil {
+				path = append(path, svgSegment{op: 'M', pts: [3]svgPoint{p}})
+				cur, start = p, p
+				// Further coordinates are lines
+				if cmd == 'M' {
+					cmd = 'L'
+				} else {
+					cmd = 'l'
+				}
+			}
+		case 'L':
+			var p svgPoint
+			if p, err = pt(); err == nil {
+				path = append(path, svgSegment{op: 'L', pts: [3]svgPoint{p}})
+				cur = p
+			}
+		case 'H', 'V':
+			var v float64
+			if v, err = sc.number(); err == nil {
+				p := cur
+				switch {
+				case upper == 'H' && rel:
+					p.x += v
+				case upper == 'H':
+					p.x = v
+				case rel:
+					p.y += v
+				default:
+					p.y = v
+				}
+				path = append(path, svgSegment{op: 'L', pts: [3]svgPoint{p}})
+				cur = p
+			}
+		case 'C', 'S':
+			var c1, c2, p svgPoint
+			if upper == 'C' {
+				c1, err = pt()
+			} else {
+				c1 = reflect("CS")
+			}
+			if err == nil {
+				if c2, err = pt(); err == nil {
+					p, err = pt()
+				}
+			}
+			if err == nil {
+				path = append(path, svgSegment{op: 'C', pts: [3]svgPoint{c1, c2, p}})
+				ctrl, cur = c2, p
+			}
+		case 'Q', 'T':
+			var c, p svgPoint
+			if upper == 'Q' {
+				c, err = pt()
+			} else {
+				c = reflect("QT")
+			}
+			if err == nil {
+				p, err = pt()
+			}
+			if err == nil {
+				path = append(path, svgSegment{op: 'Q', pts: [3]svgPoint{c, p}})
+				ctrl, cur = c, p
+			}
+		case 'A':
+			var rx, ry, rotation float64
+			var large, sweep bool
+			var p svgPoint
+			if rx, err = sc.number(); err == nil {
+				if ry, err = sc.number(); err == nil {
+					if rotation, err = sc.number(); err == nil {
+						if large, err = sc.flag(); err == nil {
+							if sweep, err = sc.flag(); err == nil {
+								p, err = pt()
+							}
+						}
+					}
+				}
+			}
+			if err == nil {
+				path = append(path, svgArc(cur, p, rx, ry, rotation, large, sweep)...)
+				cur = p
+			}
+		case 'Z':
+			if sc.isNumber() {
+				return nil, fmt.Errorf("path data has numbers after %c", cmd)
+			}
+			path = append(path, svgSegment{op: 'Z'})
+			cur = start
+		default:
+			return nil, fmt.Errorf("unknown path command %c", cmd)
+		}
+		if err != nil {
+			return nil, err
+		}
+		prev = upper
+	}
+	return path, nil
+}
+
+// svgArc turns an elliptical arc into cubic curves, following the implementation notes of the SVG spec
+func svgArc(from, to svgPoint, rx, ry, rotation float64, large, sweep bool) []svgSegment {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || from == to {
+		return []svgSegment{{op: 'L', pts: [3]svgPoint{to}}}
+	}
+
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (from.x-to.x)/2, (from.y-to.y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	// Scale up radii which are too small to reach the end point
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx, cy := cos*cx1-sin*cy1+(from.x+to.x)/2, sin*cx1+cos*cy1+(from.y+to.y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// A cubic curve per quarter of the ellipse at most
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	k := 4.0 / 3 * math.Tan(step/4)
+	point := func(t float64) (svgPoint, svgPoint) {
+		ct, st := math.Cos(t), math.Sin(t)
+		p := svgPoint{cx + rx*ct*cos - ry*st*sin, cy + rx*ct*sin + ry*st*cos}
+		tangent := svgPoint{-rx*st*cos - ry*ct*sin, -rx*st*sin + ry*ct*cos}
+		return p, tangent
+	}
+
+	var path []svgSegment
+	for i := 0; i < n; i++ {
+		t1, t2 := theta+float64(i)*step, theta+float64(i+1)*step
+		p1, d1 := point(t1)
+		p2, d2 := point(t2)
+		if i == n-1 {
+			p2 = to
+		}
+		path = append(path, svgSegment{op: 'C', pts: [3]svgPoint{
+			{p1.x + k*d1.x, p1.y + k*d1.y},
+			{p2.x - k*d2.x, p2.y - k*d2.y},
+			p2,
+		}})
+	}
+	return path
+}
+
+// rasterize draws the shapes at the scale
+func (s *SVG) rasterize(scale float32) *image.RGBA {
+	w := int(math.Ceil(float64(s.width * scale)))
+	h := int(math.Ceil(float64(s.height * scale)))
+	pixels := image.NewRGBA(image.Rect(0, 0, maxInt(w, 1), maxInt(h, 1)))
+
+	z := vector.NewRasterizer(pixels.Rect.Dx(), pixels.Rect.Dy())
+	for _, shape := range s.shapes {
+		if shape.fill.A > 0 {
+			z.Reset(pixels.Rect.Dx(), pixels.Rect.Dy())
+			shape.fillPath(z, float64(scale))
+			z.Draw(pixels, pixels.Bounds(), image.NewUniform(shape.fill), image.Point{})
+		}
+		if shape.stroke.A > 0 {
+			z.Reset(pixels.Rect.Dx(), pixels.Rect.Dy())
+			shape.strokePath(z, float64(scale))
+			z.Draw(pixels, pixels.Bounds(), image.NewUniform(shape.stroke), image.Point{})
+		}
+	}
+	return pixels
+}
+
+// fillPath adds the path to the rasterizer; subpaths are closed, since they are filled as if they were
+func (shape *svgShape) fillPath(z *vector.Rasterizer, scale float64) {
+	f := func(p svgPoint) (float32, float32) {
+		return float32(p.x * scale), float32(p.y * scale)
+	}
+
+	open := false
+	for _, seg := range shape.path {
+		switch seg.op {
+		case 'M':
+			if open {
+				z.ClosePath()
+			}
+			z.MoveTo(f(seg.pts[0]))
+			open = true
+		case 'L':
+			z.LineTo(f(seg.pts[0]))
+		case 'Q':
+			x1, y1 := f(seg.pts[0])
+			x2, y2 := f(seg.pts[1])
+			z.QuadTo(x1, y1, x2, y2)
+		case 'C':
+			x1, y1 := f(seg.pts[0])
+			x2, y2 := f(seg.pts[1])
+			x3, y3 := f(seg.pts[2])
+			z.CubeTo(x1, y1, x2, y2, x3, y3)
+		case 'Z':
+			z.ClosePath()
+			open = false
+		}
+	}
+	if open {
+		z.ClosePath()
+	}
+}
+
+// svgPolyline is a flattened subpath
+type svgPolyline struct {
+	pts    []svgPoint
+	closed bool
+}
+
+// flatten turns the path into polylines at the scale, with curves split into lines of a few pixels
+func (shape *svgShape) flatten(scale float64) []svgPolyline {
+	var lines []svgPolyline
+	var cur *svgPolyline
+	add := func(p svgPoint) {
+		p = svgPoint{p.x * scale, p.y * scale}
+		if last := cur.pts[len(cur.pts)-1]; last != p {
+			cur.pts = append(cur.pts, p)
+		}
+	}
+	segments := func(pts ...svgPoint) int {
+		length := 0.0
+		for i := 1; i < len(pts); i++ {
+			length += math.Hypot(pts[i].x-pts[i-1].x, pts[i].y-pts[i-1].y)
+		}
+		return clampInt(int(length*scale/3)+1, 1, 100)
+	}
+
+	for _, seg := range shape.path {
+		if seg.op == 'M' || cur == nil {
+			start := svgPoint{}
+			if seg.op == 'M' {
+				start = seg.pts[0]
+			} else if cur == nil && len(lines) > 0 {
+				last := lines[len(lines)-1].pts[0]
+				start = svgPoint{last.x / scale, last.y / scale}
+			}
+			lines = append(lines, svgPolyline{pts: []svgPoint{{start.x * scale, start.y * scale}}})
+			cur = &lines[len(lines)-1]
+			if seg.op == 'M' {
+				continue
+			}
+		}
+
+		last := svgPoint{cur.pts[len(cur.pts)-1].x / scale, cur.pts[len(cur.pts)-1].y / scale}
+		switch seg.op {
+		case 'L':
+			add(seg.pts[0])
+		case 'Q':
+			n := segments(last, seg.pts[0], seg.pts[1])
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				mt := 1 - t
+				add(svgPoint{
+					mt*mt*last.x + 2*mt*t*seg.pts[0].x + t*t*seg.pts[1].x,
+					mt*mt*last.y + 2*mt*t*seg.pts[0].y + t*t*seg.pts[1].y,
+				})
+			}
+		case 'C':
+			n := segments(last, seg.pts[0], seg.pts[1], seg.pts[2])
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				mt := 1 - t
+				add(svgPoint{
+					mt*mt*mt*last.x + 3*mt*mt*t*seg.pts[0].x + 3*mt*t*t*seg.pts[1].x + t*t*t*seg.pts[2].x,
+					mt*mt*mt*last.y + 3*mt*mt*t*seg.pts[0].y + 3*mt*t*t*seg.pts[1].y + t*t*t*seg.pts[2].y,
+				})
+			}
+		case 'Z':
+			cur.closed = true
+			cur = nil
+		}
+	}
+	return lines
+}
+
+// strokePath adds the outline of the stroke to the rasterizer, as a polygon per line and a circle per
+// joint. They all wind the same way, so they add up instead of cancelling out where they overlap.
+func (shape *svgShape) strokePath(z *vector.Rasterizer, scale float64) {
+	hw := shape.strokeWidth * scale / 2
+	for _, line := range shape.flatten(scale) {
+		pts := line.pts
+		if line.closed && len(pts) > 1 && pts[0] != pts[len(pts)-1] {
+			pts = append(pts, pts[0])
+		}
+		if len(pts) < 2 {
+			if shape.lineCap == "round" && len(pts) == 1 {
+				svgCircle(z, pts[0], hw)
+			}
+			continue
+		}
+
+		for i := 1; i < len(pts); i++ {
+			p, q := pts[i-1], pts[i]
+			dx, dy := q.x-p.x, q.y-p.y
+			l := math.Hypot(dx, dy)
+			dx, dy = dx/l*hw, dy/l*hw
+
+			// Square caps extend the ends of open lines by half the width
+			if !line.closed && shape.lineCap == "square" {
+				if i == 1 {
+					p = svgPoint{p.x - dx, p.y - dy}
+				}
+				if i == len(pts)-1 {
+					q = svgPoint{q.x + dx, q.y + dy}
+				}
+			}
+			svgPolygon(z, svgPoint{p.x - dy, p.y + dx}, svgPoint{q.x - dy, q.y + dx}, svgPoint{q.x + dy, q.y - dx}, svgPoint{p.x + dy, p.y - dx})
+		}
+
+		for i, p := range pts {
+			end := i == 0 || i == len(pts)-1
+			if !end || line.closed || shape.lineCap == "round" {
+				svgCircle(z, p, hw)
+			}
+		}
+	}
+}
+
+// svgPolygon adds the polygon to the rasterizer, clockwise
+func svgPolygon(z *vector.Rasterizer, pts ...svgPoint) {
+	area := 0.0
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		area += p.x*q.y - q.x*p.y
+	}
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+
+	z.MoveTo(float32(pts[0].x), float32(pts[0].y))
+	for _, p := range pts[1:] {
+		z.LineTo(float32(p.x), float32(p.y))
+	}
+	z.ClosePath()
+}
+
+// svgCircle adds a circle to the rasterizer, clockwise
+func svgCircle(z *vector.Rasterizer, c svgPoint, r float64) {
+	n := clampInt(int(r*2), 8, 64)
+	pts := make([]svgPoint, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		pts[i] = svgPoint{c.x + r*math.Cos(a), c.y + r*math.Sin(a)}
+	}
+	svgPolygon(z, pts...)
+}
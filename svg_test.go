@@ -0,0 +1,202 @@
+package engi
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testSVG = `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10" viewBox="0 0 20 20">
+	<title>icon</title>
+	<defs><rect width="20" height="20" fill="blue"/></defs>
+	<rect width="10" height="20" fill="#f00"/>
+	<g transform="translate(10 0)" style="fill: none; stroke: lime">
+		<line x1="2" y1="10" x2="8" y2="10" stroke-width="4"/>
+	</g>
+	<circle cx="15" cy="3" r="2" fill="rgb(0, 0, 255)" opacity="0.5"/>
+</svg>`
+
+func TestRasterizeSVG(t *testing.T) {
+	svg, err := parseSVG(strings.NewReader(testSVG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svg.Width() != 10 || svg.Height() != 10 {
+		t.Fatalf("the svg should have the size of its element, not %vx%v", svg.Width(), svg.Height())
+	}
+	if len(svg.shapes) != 3 {
+		t.Fatalf("the shapes outside of defs should be parsed, not %d", len(svg.shapes))
+	}
+
+	for _, scale := range []float32{1, 2} {
+		pixels := svg.rasterize(scale)
+		if pixels.Rect.Dx() != int(10*scale) || pixels.Rect.Dy() != int(10*scale) {
+			t.Errorf("at scale %v, the svg should be rasterised at %v pixels, not %v", scale, 10*scale, pixels.Rect.Size())
+		}
+		at := func(x, y float32) color.RGBA {
+			return pixels.RGBAAt(int(x*scale), int(y*scale))
+		}
+
+		if c := at(2, 8); c != (color.RGBA{255, 0, 0, 255}) {
+			t.Errorf("at scale %v, the left half should be filled red, not %v", scale, c)
+		}
+		if c := at(7, 5); c != (color.RGBA{0, 255, 0, 255}) {
+			t.Errorf("at scale %v, the line should be stroked in lime, not %v", scale, c)
+		}
+		if c := at(7, 7.5); c.A != 0 {
+			t.Errorf("at scale %v, the stroke should be as wide as its width, but found %v", scale, c)
+		}
+		if c := at(7.5, 1.5); c.B < 120 || c.B > 135 || c.A < 120 || c.A > 135 {
+			t.Errorf("at scale %v, the circle should be half transparent blue, not %v", scale, c)
+		}
+	}
+}
+
+func TestSVGPaints(t *testing.T) {
+	svg, err := parseSVG(strings.NewReader(`<svg width="10" height="10" color="rgb(0, 0, 255)">
+	<rect width="5" height="10" fill="currentColor"/>
+	<rect x="5" width="5" height="5" fill="rgba(255, 0, 0, 0.5)" stroke="url(#gradient)"/>
+	<rect x="5" y="5" width="5" height="5" fill="#00ff0080" stroke="hsl(0, 0%, 0%)"/>
+</svg>`))
+	if err != nil {
+		t.Fatalf("paints which aren't supported should be skipped, not fail the svg: %v", err)
+	}
+
+	pixels := svg.rasterize(1)
+	if c := pixels.RGBAAt(2, 2); c != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("currentColor should be the color of the svg, not %v", c)
+	}
+	if c := pixels.RGBAAt(7, 2); c.R < 120 || c.R > 135 || c.A < 120 || c.A > 135 {
+		t.Errorf("rgba should be half transparent red, not %v", c)
+	}
+	if c := pixels.RGBAAt(7, 7); c.G < 120 || c.G > 135 || c.A < 120 || c.A > 135 {
+		t.Errorf("#RRGGBBAA should be half transparent green, not %v", c)
+	}
+}
+
+func TestSVGPathData(t *testing.T) {
+	for d, want := range map[string][]svgSegment{
+		"M1.5.5-1-2": {
+			{op: 'M', pts: [3]svgPoint{{1.5, .5}}},
+			{op: 'L', pts: [3]svgPoint{{-1, -2}}},
+		},
+		"m1 1 h2 v2 H1 z": {
+			{op: 'M', pts: [3]svgPoint{{1, 1}}},
+			{op: 'L', pts: [3]svgPoint{{3, 1}}},
+			{op: 'L', pts: [3]svgPoint{{3, 3}}},
+			{op: 'L', pts: [3]svgPoint{{1, 3}}},
+			{op: 'Z'},
+		},
+		"M0,0 Q1,1 2,0 T4,0": {
+			{op: 'M'},
+			{op: 'Q', pts: [3]svgPoint{{1, 1}, {2, 0}}},
+			{op: 'Q', pts: [3]svgPoint{{3, -1}, {4, 0}}},
+		},
+		"M0 0c0 1 1 1 1 0s1-1 1 0": {
+			{op: 'M'},
+			{op: 'C', pts: [3]svgPoint{{0, 1}, {1, 1}, {1, 0}}},
+			{op: 'C', pts: [3]svgPoint{{1, -1}, {2, -1}, {2, 0}}},
+		},
+	} {
+		path, err := svgPathData(d)
+		if err != nil {
+			t.Errorf("%q should be parsed, not %v", d, err)
+			continue
+		}
+		if len(path) != len(want) {
+			t.Errorf("%q should be %v, not %v", d, want, path)
+			continue
+		}
+		for i := range path {
+			if path[i] != want[i] {
+				t.Errorf("%q should be %v, not %v", d, want, path)
+				break
+			}
+		}
+	}
+
+	// A half circle from the left to the right, through the top
+	path, err := svgPathData("M0 0 A1 1 0 0 1 2 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 3 || path[2].pts[2] != (svgPoint{2, 0}) {
+		t.Fatalf("the arc should be two cubic curves ending at 2,0, not %v", path)
+	}
+	if top := path[1].pts[2]; math.Abs(top.x-1) > 1e-9 || math.Abs(top.y+1) > 1e-9 {
+		t.Errorf("the arc should go through 1,-1, not %v", top)
+	}
+
+	for _, d := range []string{"10 10", "M0 0 X1 1", "M0 0 A1 1 0 2 1 2 0", "M0 0 Z 1"} {
+		if _, err := svgPathData(d); err == nil {
+			t.Errorf("%q should fail to parse", d)
+		}
+	}
+}
+
+func TestSVGTransform(t *testing.T) {
+	m, err := svgTransform("translate(10, 20) scale(2) rotate(90)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := m.apply(svgPoint{1, 0}); math.Abs(p.x-10) > 1e-9 || math.Abs(p.y-22) > 1e-9 {
+		t.Errorf("transforms should be applied from right to left, not to %v", p)
+	}
+	if _, err := svgTransform("spin(5)"); err == nil {
+		t.Error("an unknown transform should fail to parse")
+	}
+}
+
+func TestLoadSVG(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	Files.Mount(fstest.MapFS{
+		"ui/icon.svg":  {Data: []byte(testSVG)},
+		"ui/ui.json":   {Data: []byte(`{"textures": [{"url": "big.svg", "scale": 4}]}`)},
+		"ui/big.svg":   {Data: []byte(testSVG)},
+		"ui/wrong.svg": {Data: []byte(`<html></html>`)},
+	})
+	Files.Add("ui/icon.svg")
+	if err := Files.AddManifest("ui/ui.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	icon := Files.SVG("icon.svg")
+	if icon == nil || Files.Image("icon.svg") != icon.Rasterized() || icon.Rasterized().Width() != 10 {
+		t.Fatal("the svg should be rasterised at its own size")
+	}
+	if big := Files.Image("big.svg"); big == nil || big.Width() != 40 {
+		t.Error("the svg should be rasterised at the scale of its settings")
+	}
+
+	ren := NewRenderComponent(icon, Point{3, 3}, "icon")
+	if icon.Scale() != 4 || icon.Rasterized().Width() != 40 {
+		t.Errorf("scaling the svg up should rasterise it again, at the next power of two %v instead of %v", 4, icon.Scale())
+	}
+	if buf := ren.generateBufferContent(); buf[10] != 30 || buf[11] != 30 {
+		t.Errorf("the svg should be drawn at its scaled size, not %vx%v", buf[10], buf[11])
+	}
+	ren.SetScale(Point{3.5, 3.5})
+	if buf := ren.generateBufferContent(); icon.Scale() != 4 || buf[10] != 35 {
+		t.Errorf("scaling the svg up within its resolution should not rasterise it again, but it's at %v", icon.Scale())
+	}
+	ren.SetScale(Point{1, 1})
+	if icon.Scale() != 4 {
+		t.Error("scaling the svg down should keep the higher resolution")
+	}
+	if w := icon.clampScale(1e6) * icon.Width(); w > maxSVGSize {
+		t.Errorf("the svg should not be rasterised larger than %d pixels, not %v", maxSVGSize, w)
+	}
+
+	Files.Add("ui/wrong.svg")
+	if err := Files.Load(func() {}); err == nil {
+		t.Error("a file without an svg element should fail to load")
+	}
+}
@@ -0,0 +1,105 @@
+package engi
+
+import (
+	"image"
+	"image/draw"
+)
+
+// NewTextureRGBA creates a texture from pixels which are generated at runtime, i.e. noise maps or minimaps.
+// The texture keeps the image as its CPU copy: draw into it, and call Update or UpdateRect to upload the
+// pixels that changed.
+func NewTextureRGBA(img *image.RGBA) *Texture {
+	t := &Texture{u2: 1, v2: 1, keep: true}
+	t.upload(rgbaImage{img})
+	return t
+}
+
+// SetRGBA replaces the pixels of the texture with the image, which may have a different size, and keeps it
+// as its CPU copy
+func (t *Texture) SetRGBA(img *image.RGBA) {
+	t.keep = true
+	t.upload(rgbaImage{img})
+}
+
+// RGBA returns the CPU copy of the pixels of the texture, which can be edited and uploaded using Update or
+// UpdateRect. Loaded images are read from their file again, and kept as an editable copy from then on; it
+// returns nil if the pixels aren't available, i.e. after the texture has been deleted.
+func (t *Texture) RGBA() *image.RGBA {
+	img, err := t.pixels()
+	if err != nil {
+		return nil
+	}
+	switch data := img.Data().(type) {
+	case *image.RGBA:
+		t.image, t.keep = img, true
+		return data
+	case image.Image:
+		b := data.Bounds()
+		rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), data, b.Min, draw.Src)
+		t.image, t.keep = rgbaImage{rgba}, true
+		return rgba
+	}
+	return nil
+}
+
+// Update uploads all pixels of the CPU copy of the texture
+func (t *Texture) Update() {
+	if rgba := t.RGBA(); rgba != nil {
+		t.UpdateRect(rgba.Bounds())
+	}
+}
+
+// UpdateRect uploads the pixels of the CPU copy within the rectangle, which is cheaper than uploading all of
+// them when only a small part has changed. The edges which have been extruded around textures in an atlas
+// aren't updated.
+func (t *Texture) UpdateRect(r image.Rectangle) {
+	rgba := t.RGBA()
+	if rgba == nil {
+		return
+	}
+	r = r.Intersect(rgba.Bounds())
+	if r.Empty() || headless || t.id == nil {
+		return
+	}
+
+	x, y := r.Min.X-rgba.Rect.Min.X, r.Min.Y-rgba.Rect.Min.Y
+	if t.atlas != nil {
+		x, y = x+t.atlasX, y+t.atlasY
+	}
+
+	Gl.BindTexture(Gl.TEXTURE_2D, t.id)
+	Gl.TexSubImage2D(Gl.TEXTURE_2D, 0, x, y, r.Dx(), r.Dy(), Gl.RGBA, Gl.UNSIGNED_BYTE, nrgbaPixels(rgba, r))
+}
+
+// glPixels returns the pixels of the image the way GL expects them
+func glPixels(img Image) interface{} {
+	if rgba, ok := img.Data().(*image.RGBA); ok {
+		return nrgbaPixels(rgba, rgba.Bounds())
+	}
+	return img.Data()
+}
+
+// nrgbaPixels copies the part of the image, as pixels which aren't premultiplied by alpha
+func nrgbaPixels(img *image.RGBA, r image.Rectangle) *image.NRGBA {
+	pixels := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(pixels, pixels.Bounds(), img, r.Min, draw.Src)
+	return pixels
+}
+
+// rgbaImage is an Image of pixels which are generated at runtime
+type rgbaImage struct {
+	*image.RGBA
+}
+
+func (i rgbaImage) Data() interface{} {
+	return i.RGBA
+}
+
+func (i rgbaImage) Width() int {
+	return i.Rect.Dx()
+}
+
+func (i rgbaImage) Height() int {
+	return i.Rect.Dy()
+}
@@ -0,0 +1,77 @@
+package engi
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTextureRGBA(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	tex := NewTextureRGBA(img)
+	if tex.Width() != 8 || tex.Height() != 4 {
+		t.Errorf("the texture should have the size of the image, not %vx%v", tex.Width(), tex.Height())
+	}
+
+	img.SetRGBA(1, 2, color.RGBA{255, 0, 0, 255})
+	tex.UpdateRect(image.Rect(1, 2, 2, 3))
+	tex.UpdateRect(image.Rect(100, 100, 200, 200))
+	if tex.RGBA() != img {
+		t.Fatal("the image should be kept as the CPU copy of the texture")
+	}
+	if c := tex.RGBA().RGBAAt(1, 2); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("the edited pixel should be read back, not %v", c)
+	}
+
+	tex.SetRGBA(image.NewRGBA(image.Rect(0, 0, 2, 2)))
+	if tex.Width() != 2 || tex.Height() != 2 {
+		t.Errorf("replacing the image should resize the texture, not %vx%v", tex.Width(), tex.Height())
+	}
+
+	translucent := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	translucent.SetRGBA(0, 0, color.RGBA{64, 0, 0, 128})
+	if c := nrgbaPixels(translucent, image.Rect(0, 0, 1, 1)).NRGBAAt(0, 0); c.R < 127 || c.R > 128 || c.A != 128 {
+		t.Errorf("uploaded pixels should not be premultiplied by alpha, not %v", c)
+	}
+
+	tex.Delete()
+	if tex.RGBA() != nil {
+		t.Error("a deleted texture should have no pixels")
+	}
+}
+
+func TestEditLoadedTexture(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	green := color.NRGBA{0, 255, 0, 255}
+	Files.Mount(fstest.MapFS{"decal.png": {Data: colorPNG(t, 4, 4, green)}})
+	Files.Add("decal.png")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	tex := Files.Image("decal.png")
+	pixels := tex.RGBA()
+	if pixels == nil || pixels.RGBAAt(3, 3) != (color.RGBA{0, 255, 0, 255}) {
+		t.Fatal("the pixels of a loaded image should be read back")
+	}
+	pixels.SetRGBA(0, 0, color.RGBA{0, 0, 0, 255})
+	tex.Update()
+	if tex.RGBA() != pixels {
+		t.Error("edits of a loaded image should be kept")
+	}
+
+	atlas, err := Files.PackAtlas("decals", []string{"decal.png"}, DefaultAtlasOptions)
+	if err != nil {
+		t.Fatalf("an edited image should be packed, not %v", err)
+	}
+	page := atlas.Pages()[0].image.Data().(*image.NRGBA)
+	if c := page.NRGBAAt(tex.atlasX, tex.atlasY); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Errorf("the edits should be packed, not %v", c)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"io/fs"
 	"path"
 	"sort"
 	"strconv"
@@ -22,12 +24,17 @@ type TMXTilesetSrc struct {
 }
 
 type TMXTileset struct {
-	Firstgid   int           `xml:"firstgid,attr"`
+	Firstgid int `xml:"firstgid,attr"`
+	// Source is the TSX file of an external tileset
+	Source     string        `xml:"source,attr"`
 	Name       string        `xml:"name,attr"`
 	TileWidth  int           `xml:"tilewidth,attr"`
 	TileHeight int           `xml:"tileheight,attr"`
 	ImageSrc   TMXTilesetSrc `xml:"image"`
 	Image      *Texture
+
+	// imageName is the name of the image asset, resolved relative to the file which references it
+	imageName string
 }
 
 type TMXLayer struct {
@@ -63,6 +70,9 @@ type TMXImgLayer struct {
 	X      float64   `xml:"x,attr"`
 	Y      float64   `xml:"y,attr"`
 	ImgSrc TMXImgSrc `xml:"image"`
+
+	// imageName is the name of the image asset, resolved relative to the TMX file
+	imageName string
 }
 
 type TMXLevel struct {
@@ -82,18 +92,34 @@ func (t ByFirstgid) Len() int           { return len(t) }
 func (t ByFirstgid) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
 func (t ByFirstgid) Less(i, j int) bool { return t[i].Firstgid < t[j].Firstgid }
 
-// MUST BE base64 ENCODED and COMPRESSED WITH zlib!
-func createLevelFromTmx(r Resource) (*Level, error) {
+// decodeTmx reads the TMX file and decompresses its layers; it does not need the main thread, so it can be
+// done in the background. Layers MUST BE base64 ENCODED and COMPRESSED WITH zlib!
+func decodeTmx(r Resource) (*TMXLevel, error) {
 	tlvl := &TMXLevel{}
-	lvl := &Level{}
 
-	tmx, err := readTmx(r.url)
+	tmx, err := r.ReadAll()
 	if err != nil {
-		return lvl, err
+		return tlvl, err
+	}
+
+	if err := xml.Unmarshal(tmx, &tlvl); err != nil {
+		return tlvl, fmt.Errorf("could not unmarshal XML: %v", err)
 	}
 
-	if err := xml.Unmarshal([]byte(tmx), &tlvl); err != nil {
-		fmt.Printf("Error unmarshalling XML: %v", err)
+	// Resolve the images relative to the files that reference them, reading external tilesets along the way
+	for i := range tlvl.Tilesets {
+		ts := &tlvl.Tilesets[i]
+		file := r.url
+		if ts.Source != "" {
+			file = resolvePath(r.url, ts.Source)
+			if err := readTsx(r.fs(), file, ts); err != nil {
+				return tlvl, err
+			}
+		}
+		ts.imageName = resolvePath(file, ts.ImageSrc.Source)
+	}
+	for i := range tlvl.ImgLayers {
+		tlvl.ImgLayers[i].imageName = resolvePath(r.url, tlvl.ImgLayers[i].ImgSrc.Source)
 	}
 
 	// Extract the tile mappings from the compressed data at each layer
@@ -105,27 +131,27 @@ func createLevelFromTmx(r Resource) (*Level, error) {
 
 		// Decode it out of base64
 		if n, err := base64.StdEncoding.Decode(layer.CompData, layer.CompData); err != nil {
-			fmt.Printf("error after %d bytes: %v", n, err)
-			return lvl, err
+			return tlvl, fmt.Errorf("could not decode layer %s after %d bytes: %v", layer.Name, n, err)
 		}
 
 		// Decompress
 		b := bytes.NewReader(layer.CompData)
 		zlr, err := zlib.NewReader(b)
 		if err != nil {
-			fmt.Printf("error: %v", err)
-			return lvl, err
+			return tlvl, fmt.Errorf("could not decompress layer %s: %v", layer.Name, err)
 		}
 
 		tm := make([]uint32, 0)
 		var nextInt uint32
 		for {
 			err = binary.Read(zlr, binary.LittleEndian, &nextInt)
-			if err != nil {
-				// this is -generally- EOF
-				//fmt.Println("binary.Read failed:", err)
+			if err == io.EOF {
 				break
 			}
+			if err != nil {
+				zlr.Close()
+				return tlvl, fmt.Errorf("could not decompress layer %s: %v", layer.Name, err)
+			}
 			tm = append(tm, nextInt)
 		}
 		layer.TileMapping = tm
@@ -133,9 +159,54 @@ func createLevelFromTmx(r Resource) (*Level, error) {
 		zlr.Close()
 	}
 
+	logDebug(LogTMX, "decoded level", "url", r.url, "layers", len(tlvl.Layers), "tilesets", len(tlvl.Tilesets))
+	return tlvl, nil
+}
+
+// readTsx reads the external tileset into ts, keeping its first GID and source
+func readTsx(fsys fs.FS, url string, ts *TMXTileset) error {
+	tsx, err := fs.ReadFile(fsys, url)
+	if err != nil {
+		return err
+	}
+
+	firstgid, source := ts.Firstgid, ts.Source
+	*ts = TMXTileset{}
+	if err := xml.Unmarshal(tsx, ts); err != nil {
+		return fmt.Errorf("could not unmarshal tileset %s: %v", url, err)
+	}
+	ts.Firstgid, ts.Source = firstgid, source
+	return nil
+}
+
+// levelImage returns the image by its full name, falling back to its short name for images that were loaded
+// from elsewhere, as long as that is unambiguous. It also returns the full name of the image it found, which
+// is the name the level retains it by.
+func levelImage(name string) (*Texture, string, error) {
+	a, err := Files.lookup("image", name)
+	if errors.Is(err, ErrMissingAsset) {
+		a, err = Files.lookup("image", path.Base(name))
+	}
+	if err != nil {
+		return nil, name, err
+	}
+	img, err := Files.GetImage(a.name)
+	return img, a.name, err
+}
+
+// createLevel creates the Level from the decoded TMXLevel, using the tileset images which have already been
+// loaded by Files
+func createLevel(tlvl *TMXLevel) (*Level, error) {
+	lvl := &Level{}
+
 	// Load in the images needed for the tilesets
 	for k, ts := range tlvl.Tilesets {
-		ts.Image = Files.Image(path.Base(ts.ImageSrc.Source))
+		img, name, err := levelImage(ts.imageName)
+		if err != nil {
+			return lvl, fmt.Errorf("tileset image not loaded: %w", err)
+		}
+		ts.Image = img
+		lvl.images = append(lvl.images, name)
 		tlvl.Tilesets[k] = ts
 	}
 
@@ -160,13 +231,27 @@ func createLevelFromTmx(r Resource) (*Level, error) {
 
 	lvl.Tiles = createLevelTiles(lvl, lvlLayers, lvlTileset)
 
-	for _, o := range tlvl.ObjGroups[0].Objects {
-		p := o.Polylines[0].Points
-		lvl.LineBounds = append(lvl.LineBounds, pointStringToLines(p, o.X, o.Y)...)
+	// The bounds are the polylines of the first object group; levels don't need to have any, and other objects,
+	// like rectangles, are left out
+	if len(tlvl.ObjGroups) > 0 {
+		for _, o := range tlvl.ObjGroups[0].Objects {
+			if len(o.Polylines) == 0 {
+				continue
+			}
+			lines, err := pointStringToLines(o.Polylines[0].Points, o.X, o.Y)
+			if err != nil {
+				return lvl, fmt.Errorf("could not read polyline of object at %v,%v: %w", o.X, o.Y, err)
+			}
+			lvl.LineBounds = append(lvl.LineBounds, lines...)
+		}
 	}
 
 	for i := 0; i < len(tlvl.ImgLayers); i++ {
-		curImg := Files.Image(path.Base(tlvl.ImgLayers[i].ImgSrc.Source))
+		curImg, name, err := levelImage(tlvl.ImgLayers[i].imageName)
+		if err != nil {
+			return lvl, fmt.Errorf("image layer not loaded: %w", err)
+		}
+		lvl.images = append(lvl.images, name)
 		curX := float32(tlvl.ImgLayers[i].X)
 		curY := float32(tlvl.ImgLayers[i].Y)
 		reg := NewRegion(curImg, 0, 0, curImg.width, curImg.height)
@@ -176,11 +261,14 @@ func createLevelFromTmx(r Resource) (*Level, error) {
 	return lvl, nil
 }
 
-func pointStringToLines(str string, xOff, yOff float64) []Line {
+func pointStringToLines(str string, xOff, yOff float64) ([]Line, error) {
 	pts := strings.Split(str, " ")
 	floatPts := make([][]float64, len(pts))
 	for i, x := range pts {
 		pt := strings.Split(x, ",")
+		if len(pt) != 2 {
+			return nil, fmt.Errorf("point %q should be x,y", x)
+		}
 		floatPts[i] = make([]float64, 2)
 		floatPts[i][0], _ = strconv.ParseFloat(pt[0], 64)
 		floatPts[i][1], _ = strconv.ParseFloat(pt[1], 64)
@@ -200,13 +288,5 @@ func pointStringToLines(str string, xOff, yOff float64) []Line {
 		lines = append(lines, newLine)
 	}
 
-	return lines
-}
-
-func readTmx(url string) (string, error) {
-	file, err := ioutil.ReadFile(url)
-	if err != nil {
-		return "", err
-	}
-	return string(file), nil
+	return lines, nil
 }
@@ -0,0 +1,150 @@
+package engi
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+// tmxMap returns a TMX file of a single tile with the given GID, using the tileset XML
+func tmxMap(t *testing.T, tileset string, gid uint32) string {
+	return tmxMapWithObjects(t, tileset, gid, `<objectgroup name="bounds"><object x="0" y="0"><polyline points="0,0 4,0"/></object></objectgroup>`)
+}
+
+// tmxMapWithObjects is like tmxMap, with the given object groups
+func tmxMapWithObjects(t *testing.T, tileset string, gid uint32, objects string) string {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	binary.Write(zw, binary.LittleEndian, gid)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return fmt.Sprintf(`<map width="1" height="1" tilewidth="4" tileheight="4">
+	%s
+	<layer name="ground" width="1" height="1"><data encoding="base64" compression="zlib">%s</data></layer>
+	%s
+</map>`, tileset, base64.StdEncoding.EncodeToString(buf.Bytes()), objects)
+}
+
+func TestRelativeTilesets(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	Files.Mount(fstest.MapFS{
+		"levels/forest/tiles.png": {Data: []byte(encodePNG(t, 4, 4))},
+		"levels/forest/map.tmx": {Data: []byte(tmxMap(t,
+			`<tileset firstgid="1" name="forest" tilewidth="4" tileheight="4"><image source="tiles.png"/></tileset>`, 1))},
+
+		"levels/desert/tiles.png": {Data: []byte(encodePNG(t, 8, 4))},
+		"levels/desert/map.tmx": {Data: []byte(tmxMap(t,
+			`<tileset firstgid="1" source="tilesets/desert.tsx"/>`, 2))},
+		"levels/desert/tilesets/desert.tsx": {Data: []byte(
+			`<tileset name="desert" tilewidth="4" tileheight="4"><image source="../tiles.png"/></tileset>`)},
+	})
+
+	Files.AddFromDir("levels", true)
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatalf("loading should succeed, not %v", err)
+	}
+
+	if _, err := Files.GetImage("tiles.png"); !errors.Is(err, ErrAssetCollision) {
+		t.Errorf("tiles.png should be ambiguous, not %v", err)
+	}
+	forestTiles, err := Files.GetImage("levels/forest/tiles.png")
+	if err != nil {
+		t.Fatalf("levels/forest/tiles.png should be loaded, not %v", err)
+	}
+	desertTiles, err := Files.GetImage("./levels/desert/tiles.png")
+	if err != nil {
+		t.Fatalf("levels/desert/tiles.png should be loaded, not %v", err)
+	}
+
+	forest, err := Files.GetLevel("levels/forest/map.tmx")
+	if err != nil {
+		t.Fatalf("the forest should be loaded, not %v", err)
+	}
+	if tex := forest.Tiles[0].Image.texture; tex != forestTiles {
+		t.Error("the forest should use the tiles next to it")
+	}
+
+	desert, err := Files.GetLevel("levels/desert/map.tmx")
+	if err != nil {
+		t.Fatalf("the desert should be loaded, not %v", err)
+	}
+	if tex := desert.Tiles[0].Image.texture; tex != desertTiles {
+		t.Error("the desert should use the tiles relative to its tileset")
+	}
+	if u, _, _, _ := desert.Tiles[0].Image.View(); u != 0.5 {
+		t.Errorf("the desert should use the second tile, at u 0.5, not %v", u)
+	}
+
+	if err := Files.Unload("levels/forest/tiles.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Files.GetImage("tiles.png"); err != nil {
+		t.Errorf("tiles.png should be unambiguous once the forest tiles are unloaded, not %v", err)
+	}
+}
+
+func TestLevelImageFallback(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	Files.Mount(fstest.MapFS{
+		"images/tiles.png": {Data: []byte(encodePNG(t, 4, 4))},
+		"maps/map.tmx": {Data: []byte(tmxMap(t,
+			`<tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4"><image source="tiles.png"/></tileset>`, 1))},
+	})
+	Files.Add("images/tiles.png", "maps/map.tmx")
+	if err := Files.Load(func() {}); err != nil {
+		t.Fatalf("the level should find its image by its short name, not %v", err)
+	}
+
+	lvl, err := Files.GetLevel("maps/map.tmx")
+	if err != nil {
+		t.Fatalf("the level should be loaded, not %v", err)
+	}
+	if len(lvl.images) != 1 || lvl.images[0] != "images/tiles.png" {
+		t.Errorf("the level should retain the image it found, not %v", lvl.images)
+	}
+	if refs := Files.assets["images/tiles.png"].refs; refs != 2 {
+		t.Errorf("the image should be retained by the level, but has %d references", refs)
+	}
+
+	if err := Files.Unload("maps/map.tmx"); err != nil {
+		t.Fatal(err)
+	}
+	if refs := Files.assets["images/tiles.png"].refs; refs != 1 {
+		t.Errorf("unloading the level should release the image, but it has %d references", refs)
+	}
+}
+
+func TestLevelWithoutBounds(t *testing.T) {
+	headless = true
+	Files = NewLoader()
+
+	tileset := `<tileset firstgid="1" name="tiles" tilewidth="4" tileheight="4"><image source="tiles.png"/></tileset>`
+	Files.Mount(fstest.MapFS{
+		"tiles.png":   {Data: []byte(encodePNG(t, 4, 4))},
+		"empty.tmx":   {Data: []byte(tmxMapWithObjects(t, tileset, 1, ""))},
+		"objects.tmx": {Data: []byte(tmxMapWithObjects(t, tileset, 1, `<objectgroup name="spawns"><object x="2" y="2" width="1" height="1"/></objectgroup>`))},
+		"broken.tmx":  {Data: []byte(tmxMapWithObjects(t, tileset, 1, `<objectgroup name="bounds"><object x="0" y="0"><polyline points=""/></object></objectgroup>`))},
+	})
+	Files.Add("tiles.png", "empty.tmx", "objects.tmx", "broken.tmx")
+	var loadErr *LoadError
+	if err := Files.Load(func() {}); !errors.As(err, &loadErr) || len(loadErr.Errors) != 1 || loadErr.Errors[0].Name != "broken.tmx" {
+		t.Fatalf("only the level with a malformed polyline should fail to load, not %v", err)
+	}
+
+	for _, name := range []string{"empty.tmx", "objects.tmx"} {
+		if lvl := Files.Level(name); lvl == nil || len(lvl.LineBounds) != 0 {
+			t.Errorf("%s should be loaded without bounds, not %v", name, lvl)
+		}
+	}
+}
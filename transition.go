@@ -0,0 +1,166 @@
+package engi
+
+import (
+	"time"
+)
+
+// SceneEffect describes how a Scene is rendered while a Transition is running
+type SceneEffect struct {
+	// Alpha is multiplied with the transparency of everything in the Scene
+	Alpha float32
+	// Offset moves everything in the Scene by the given number of pixels
+	Offset Point
+}
+
+// noEffect renders the Scene as it is
+var noEffect = SceneEffect{Alpha: 1}
+
+// Transition determines how Scenes are rendered while switching between them
+type Transition interface {
+	// Duration returns how long the Transition takes
+	Duration() time.Duration
+	// Effect returns how a Scene should be rendered at the given progress, which ranges from 0 to 1. Entering
+	// is true for the Scenes that are becoming visible, and false for those that are disappearing.
+	Effect(progress float32, entering bool) SceneEffect
+}
+
+type transitionFunc struct {
+	duration time.Duration
+	effect   func(progress float32, entering bool) SceneEffect
+}
+
+func (t transitionFunc) Duration() time.Duration {
+	return t.duration
+}
+
+func (t transitionFunc) Effect(progress float32, entering bool) SceneEffect {
+	return t.effect(progress, entering)
+}
+
+// Fade fades the old Scene out to the background color during the first half of d, and fades the new one
+// in during the second half
+func Fade(d time.Duration) Transition {
+	return transitionFunc{d, func(progress float32, entering bool) SceneEffect {
+		if entering {
+			return SceneEffect{Alpha: clamp01(progress*2 - 1)}
+		}
+		return SceneEffect{Alpha: clamp01(1 - progress*2)}
+	}}
+}
+
+// Crossfade fades the old Scene out, while fading the new one in
+func Crossfade(d time.Duration) Transition {
+	return transitionFunc{d, func(progress float32, entering bool) SceneEffect {
+		if entering {
+			return SceneEffect{Alpha: progress}
+		}
+		return SceneEffect{Alpha: 1 - progress}
+	}}
+}
+
+// Slide moves the old Scene off the screen, while moving the new one in. Direction is the direction in which
+// both Scenes move, i.e. Point{-1, 0} brings in the new Scene from the right.
+func Slide(d time.Duration, direction Point) Transition {
+	return transitionFunc{d, func(progress float32, entering bool) SceneEffect {
+		progress = EaseInOutQuad(progress)
+		if entering {
+			progress -= 1
+		}
+		return SceneEffect{
+			Alpha:  1,
+			Offset: Point{direction.X * Width() * progress, direction.Y * Height() * progress},
+		}
+	}}
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// transitionState keeps track of the running Transition
+type transitionState struct {
+	transition Transition
+	elapsed    float32
+
+	entering []*sceneWrapper
+	leaving  []*sceneWrapper
+	// leavingOnTop indicates whether the leaving Scenes should be rendered on top of the others
+	leavingOnTop bool
+}
+
+// startTransition starts t, given the Scenes that were visible before the Scene stack changed
+func startTransition(t Transition, before []*sceneWrapper, leavingOnTop bool) {
+	// Whatever was running, is done now
+	if transitioning != nil {
+		for _, w := range transitioning.entering {
+			w.effect = noEffect
+		}
+		for _, w := range transitioning.leaving {
+			w.effect = noEffect
+		}
+		transitioning = nil
+	}
+
+	if t == nil || t.Duration() <= 0 {
+		return
+	}
+
+	after := visibleScenes()
+	ts := &transitionState{
+		transition:   t,
+		entering:     difference(after, before),
+		leaving:      difference(before, after),
+		leavingOnTop: leavingOnTop,
+	}
+	transitioning = ts
+	ts.apply(0)
+}
+
+// advance moves the Transition forward by dt seconds, and returns false once it has finished
+func (ts *transitionState) advance(dt float32) bool {
+	ts.elapsed += dt
+
+	progress := ts.elapsed / float32(ts.transition.Duration().Seconds())
+	if progress >= 1 {
+		for _, w := range ts.entering {
+			w.effect = noEffect
+		}
+		for _, w := range ts.leaving {
+			w.effect = noEffect
+		}
+		return false
+	}
+
+	ts.apply(progress)
+	return true
+}
+
+func (ts *transitionState) apply(progress float32) {
+	for _, w := range ts.entering {
+		w.effect = ts.transition.Effect(progress, true)
+	}
+	for _, w := range ts.leaving {
+		w.effect = ts.transition.Effect(progress, false)
+	}
+}
+
+// difference returns the Scenes in a, which are not in b
+func difference(a, b []*sceneWrapper) []*sceneWrapper {
+	var diff []*sceneWrapper
+Outer:
+	for _, w := range a {
+		for _, other := range b {
+			if w == other {
+				continue Outer
+			}
+		}
+		diff = append(diff, w)
+	}
+	return diff
+}
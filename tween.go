@@ -0,0 +1,429 @@
+package engi
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+// TweenTarget is a value that can be tweened; it consists of one or more float32 channels,
+// i.e. the X and Y of a Point
+type TweenTarget interface {
+	Get() []float32
+	Set(values []float32)
+}
+
+type funcTarget struct {
+	get func() []float32
+	set func([]float32)
+}
+
+func (f funcTarget) Get() []float32       { return f.get() }
+func (f funcTarget) Set(values []float32) { f.set(values) }
+
+// FloatTarget tweens an arbitrary float32, given its getter and setter
+func FloatTarget(get func() float32, set func(float32)) TweenTarget {
+	return funcTarget{
+		func() []float32 { return []float32{get()} },
+		func(v []float32) { set(v[0]) },
+	}
+}
+
+// PositionTarget tweens the Position of the SpaceComponent
+func PositionTarget(space *SpaceComponent) TweenTarget {
+	return funcTarget{
+		func() []float32 { return []float32{space.Position.X, space.Position.Y} },
+		func(v []float32) { space.Position.X, space.Position.Y = v[0], v[1] },
+	}
+}
+
+// SizeTarget tweens the Width and Height of the SpaceComponent
+func SizeTarget(space *SpaceComponent) TweenTarget {
+	return funcTarget{
+		func() []float32 { return []float32{space.Width, space.Height} },
+		func(v []float32) { space.Width, space.Height = v[0], v[1] },
+	}
+}
+
+// TransparencyTarget tweens the Transparency of the RenderComponent
+func TransparencyTarget(render *RenderComponent) TweenTarget {
+	return FloatTarget(
+		func() float32 { return render.Transparency },
+		render.SetTransparency,
+	)
+}
+
+// ColorTarget tweens the Color of the RenderComponent; its channels are the R, G, B and A values, ranging from 0 to 255
+func ColorTarget(render *RenderComponent) TweenTarget {
+	return funcTarget{
+		func() []float32 {
+			c := color.NRGBAModel.Convert(render.Color).(color.NRGBA)
+			return []float32{float32(c.R), float32(c.G), float32(c.B), float32(c.A)}
+		},
+		func(v []float32) {
+			render.SetColor(color.NRGBA{colorChannel(v[0]), colorChannel(v[1]), colorChannel(v[2]), colorChannel(v[3])})
+		},
+	}
+}
+
+func colorChannel(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// ScaleTarget tweens the scale of the RenderComponent
+func ScaleTarget(render *RenderComponent) TweenTarget {
+	return funcTarget{
+		func() []float32 { s := render.Scale(); return []float32{s.X, s.Y} },
+		func(v []float32) { render.SetScale(Point{v[0], v[1]}) },
+	}
+}
+
+// CameraPositionTarget tweens the position of the Camera of the current Scene
+func CameraPositionTarget() TweenTarget {
+	return funcTarget{
+		func() []float32 { return []float32{cam.X(), cam.Y()} },
+		func(v []float32) { cam.moveToX(v[0]); cam.moveToY(v[1]) },
+	}
+}
+
+// CameraZoomTarget tweens the zoom level of the Camera of the current Scene
+func CameraZoomTarget() TweenTarget {
+	return FloatTarget(cam.Z, cam.zoomTo)
+}
+
+// Tweener is either a single Tween, or a group of them
+type Tweener interface {
+	// length returns the total duration in seconds, including delays and repetitions
+	length() float32
+	// seek updates the targets to the state at t seconds since the start
+	seek(t float32)
+	// started indicates whether or not the Tweener has started changing its targets
+	started() bool
+}
+
+// timing holds the settings that Tweens and TweenGroups have in common
+type timing struct {
+	// Delay is the time to wait before starting
+	Delay time.Duration
+	// Repeat is the number of times to run again after the first run; -1 repeats forever
+	Repeat int
+	// Yoyo indicates whether every other repetition should run backwards
+	Yoyo bool
+}
+
+func (tm *timing) total(cycle float32) float32 {
+	if tm.Repeat < 0 {
+		return float32(math.Inf(1))
+	}
+	return float32(tm.Delay.Seconds()) + cycle*float32(tm.Repeat+1)
+}
+
+// local maps t, the time since the start, onto the time within a single cycle
+func (tm *timing) local(t, cycle float32) float32 {
+	t -= float32(tm.Delay.Seconds())
+	if t <= 0 {
+		return 0
+	}
+	if cycle <= 0 {
+		return cycle
+	}
+
+	n := int(t / cycle)
+	if tm.Repeat >= 0 && n > tm.Repeat {
+		// Finished: stay at the end of the last cycle
+		if tm.Yoyo && tm.Repeat%2 == 1 {
+			return 0
+		}
+		return cycle
+	}
+
+	local := t - float32(n)*cycle
+	if tm.Yoyo && n%2 == 1 {
+		local = cycle - local
+	}
+	return local
+}
+
+// Tween changes a TweenTarget from its current values to the given values, over the given Duration
+type Tween struct {
+	timing
+
+	// Duration is the time a single run takes
+	Duration time.Duration
+	// Easing is the EaseFunc used, EaseLinear if nil
+	Easing EaseFunc
+
+	target TweenTarget
+	from   []float32
+	to     []float32
+	values []float32
+
+	running bool
+	last    float32
+}
+
+// NewTween creates a Tween which changes the target to the values in to; the current values of the target
+// are read whenever the Tween starts, and those for which to has no value are left as they are
+func NewTween(target TweenTarget, duration time.Duration, to ...float32) *Tween {
+	return &Tween{
+		Duration: duration,
+		target:   target,
+		to:       to,
+	}
+}
+
+func (tw *Tween) length() float32 {
+	return tw.total(float32(tw.Duration.Seconds()))
+}
+
+func (tw *Tween) started() bool {
+	return tw.running
+}
+
+func (tw *Tween) seek(t float32) {
+	if !tw.running {
+		if t <= float32(tw.Delay.Seconds()) && t < tw.length() {
+			return
+		}
+
+		tw.from = tw.target.Get()
+		tw.values = make([]float32, len(tw.from))
+		tw.running = true
+		tw.last = -1
+	}
+
+	duration := float32(tw.Duration.Seconds())
+	local := tw.local(t, duration)
+	if local == tw.last {
+		return
+	}
+	tw.last = local
+
+	progress := float32(1)
+	if duration > 0 {
+		progress = local / duration
+	}
+
+	easing := tw.Easing
+	if easing == nil {
+		easing = EaseLinear
+	}
+	progress = easing(progress)
+
+	for i := range tw.values {
+		tw.values[i] = tw.from[i]
+		if i < len(tw.to) {
+			tw.values[i] += (tw.to[i] - tw.from[i]) * progress
+		}
+	}
+	tw.target.Set(tw.values)
+}
+
+// TweenGroup runs a number of Tweeners either one after the other, or all at the same time
+type TweenGroup struct {
+	timing
+
+	parallel bool
+	children []Tweener
+}
+
+// Sequence creates a TweenGroup which runs the Tweeners one after the other
+func Sequence(tweeners ...Tweener) *TweenGroup {
+	return &TweenGroup{children: tweeners}
+}
+
+// Parallel creates a TweenGroup which runs the Tweeners at the same time
+func Parallel(tweeners ...Tweener) *TweenGroup {
+	return &TweenGroup{children: tweeners, parallel: true}
+}
+
+func (g *TweenGroup) cycle() float32 {
+	var cycle float32
+	for _, child := range g.children {
+		if l := child.length(); g.parallel && l > cycle {
+			cycle = l
+		} else if !g.parallel {
+			cycle += l
+		}
+	}
+	return cycle
+}
+
+func (g *TweenGroup) length() float32 {
+	return g.total(g.cycle())
+}
+
+func (g *TweenGroup) started() bool {
+	for _, child := range g.children {
+		if child.started() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *TweenGroup) seek(t float32) {
+	if !g.started() && t <= float32(g.Delay.Seconds()) && t < g.length() {
+		return
+	}
+
+	t = g.local(t, g.cycle())
+
+	if g.parallel {
+		for _, child := range g.children {
+			child.seek(t)
+		}
+		return
+	}
+
+	// Children that are not currently running are finished (or rewound) first, so the
+	// active child always has the final say when multiple children share a target
+	var (
+		active      Tweener
+		activeLocal float32
+		start       float32
+	)
+	for _, child := range g.children {
+		l := child.length()
+		switch {
+		case t >= start+l:
+			child.seek(l)
+		case t >= start:
+			active, activeLocal = child, t-start
+		case child.started():
+			child.seek(0)
+		}
+		start += l
+	}
+
+	if active != nil {
+		active.seek(activeLocal)
+	}
+}
+
+// TweenCompleteMessage is dispatched whenever a Tweener, added to a TweenComponent or the TweenSystem, has finished
+type TweenCompleteMessage struct {
+	Tweener Tweener
+	// Entity is the Entity the Tweener belonged to, or nil if it was added to the TweenSystem directly
+	Entity *ecs.Entity
+}
+
+func (TweenCompleteMessage) Type() string {
+	return "TweenCompleteMessage"
+}
+
+type runningTween struct {
+	tweener Tweener
+	elapsed float32
+}
+
+// TweenComponent holds the Tweeners which are running for an Entity
+type TweenComponent struct {
+	tweens []*runningTween
+}
+
+func (*TweenComponent) Type() string {
+	return "TweenComponent"
+}
+
+// Add starts running the Tweener
+func (tc *TweenComponent) Add(t Tweener) {
+	tc.tweens = append(tc.tweens, &runningTween{tweener: t})
+}
+
+// Stop stops running the Tweener, leaving its targets as they currently are
+func (tc *TweenComponent) Stop(t Tweener) {
+	for i, rt := range tc.tweens {
+		if rt.tweener == t {
+			tc.tweens = append(tc.tweens[:i], tc.tweens[i+1:]...)
+			return
+		}
+	}
+}
+
+// Running indicates whether or not any Tweeners are running
+func (tc *TweenComponent) Running() bool {
+	return len(tc.tweens) > 0
+}
+
+// TweenSystem runs the Tweeners of each TweenComponent. Tweeners which do not belong to an Entity
+// (i.e. those targeting the Camera) can be added to the TweenSystem directly.
+type TweenSystem struct {
+	*ecs.System
+
+	// IgnoreTimeScale indicates whether tweens should keep their speed when Time is paused or slowed down
+	IgnoreTimeScale bool
+
+	self      *ecs.Entity
+	component *TweenComponent
+}
+
+func (*TweenSystem) Type() string {
+	return "TweenSystem"
+}
+
+func (ts *TweenSystem) Unscaled() bool {
+	return ts.IgnoreTimeScale
+}
+
+func (ts *TweenSystem) New(*ecs.World) {
+	ts.System = ecs.NewSystem()
+
+	ts.component = &TweenComponent{}
+	ts.self = ecs.NewEntity([]string{ts.Type()})
+	ts.self.AddComponent(ts.component)
+	ts.AddEntity(ts.self)
+}
+
+// Add starts running a Tweener which does not belong to an Entity
+func (ts *TweenSystem) Add(t Tweener) {
+	ts.component.Add(t)
+}
+
+// Stop stops running a Tweener which was added using Add
+func (ts *TweenSystem) Stop(t Tweener) {
+	ts.component.Stop(t)
+}
+
+func (ts *TweenSystem) Update(entity *ecs.Entity, dt float32) {
+	var (
+		tc *TweenComponent
+		ok bool
+	)
+
+	if tc, ok = entity.ComponentFast(tc).(*TweenComponent); !ok {
+		return
+	}
+
+	running := tc.tweens[:0]
+	var finished []Tweener
+	for _, rt := range tc.tweens {
+		rt.elapsed += dt
+
+		if l := rt.tweener.length(); rt.elapsed >= l {
+			rt.tweener.seek(l)
+			finished = append(finished, rt.tweener)
+			continue
+		}
+
+		rt.tweener.seek(rt.elapsed)
+		running = append(running, rt)
+	}
+	tc.tweens = running
+
+	owner := entity
+	if entity == ts.self {
+		owner = nil
+	}
+	for _, t := range finished {
+		Mailbox.Dispatch(TweenCompleteMessage{Tweener: t, Entity: owner})
+	}
+}
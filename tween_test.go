@@ -0,0 +1,152 @@
+package engi
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/paked/engi/ecs"
+)
+
+func TestEasingEndpoints(t *testing.T) {
+	easings := map[string]EaseFunc{
+		"Linear": EaseLinear,
+		"InQuad": EaseInQuad, "OutQuad": EaseOutQuad, "InOutQuad": EaseInOutQuad,
+		"InCubic": EaseInCubic, "OutCubic": EaseOutCubic, "InOutCubic": EaseInOutCubic,
+		"InQuart": EaseInQuart, "OutQuart": EaseOutQuart, "InOutQuart": EaseInOutQuart,
+		"InQuint": EaseInQuint, "OutQuint": EaseOutQuint, "InOutQuint": EaseInOutQuint,
+		"InSine": EaseInSine, "OutSine": EaseOutSine, "InOutSine": EaseInOutSine,
+		"InExpo": EaseInExpo, "OutExpo": EaseOutExpo, "InOutExpo": EaseInOutExpo,
+		"InCirc": EaseInCirc, "OutCirc": EaseOutCirc, "InOutCirc": EaseInOutCirc,
+		"InElastic": EaseInElastic, "OutElastic": EaseOutElastic, "InOutElastic": EaseInOutElastic,
+		"InBack": EaseInBack, "OutBack": EaseOutBack, "InOutBack": EaseInOutBack,
+		"InBounce": EaseInBounce, "OutBounce": EaseOutBounce, "InOutBounce": EaseInOutBounce,
+	}
+
+	for name, ease := range easings {
+		if v := ease(0); math.Abs(float64(v)) > 1e-3 {
+			t.Errorf("Ease%s(0) should be 0, not %v", name, v)
+		}
+		if v := ease(1); math.Abs(float64(v-1)) > 1e-3 {
+			t.Errorf("Ease%s(1) should be 1, not %v", name, v)
+		}
+	}
+}
+
+func newTestTweenSystem() (*TweenSystem, *ecs.World) {
+	Mailbox = &MessageManager{}
+
+	w := &ecs.World{}
+	w.New()
+
+	ts := &TweenSystem{}
+	w.AddSystem(ts)
+	return ts, w
+}
+
+func TestTweenYoyo(t *testing.T) {
+	ts, w := newTestTweenSystem()
+
+	var value float32
+	tw := NewTween(FloatTarget(func() float32 { return value }, func(v float32) { value = v }), time.Second, 10)
+	tw.Repeat = 1
+	tw.Yoyo = true
+	ts.Add(tw)
+
+	w.Update(0.5)
+	if value != 5 {
+		t.Errorf("value should be 5 halfway, not %v", value)
+	}
+
+	w.Update(0.5)
+	if value != 10 {
+		t.Errorf("value should be 10 after the first run, not %v", value)
+	}
+
+	w.Update(0.75)
+	if value != 2.5 {
+		t.Errorf("value should be 2.5 when going back, not %v", value)
+	}
+
+	w.Update(1)
+	if value != 0 {
+		t.Errorf("value should be back at 0 when finished, not %v", value)
+	}
+}
+
+func TestTweenSequence(t *testing.T) {
+	ts, w := newTestTweenSystem()
+
+	space := &SpaceComponent{}
+	seq := Sequence(
+		NewTween(PositionTarget(space), time.Second, 10, 0),
+		NewTween(PositionTarget(space), time.Second, 10, 10),
+	)
+
+	var completed []Tweener
+	Mailbox.Listen("TweenCompleteMessage", func(msg Message) {
+		completed = append(completed, msg.(TweenCompleteMessage).Tweener)
+	})
+
+	e := ecs.NewEntity([]string{ts.Type()})
+	tc := &TweenComponent{}
+	tc.Add(seq)
+	e.AddComponent(tc)
+	w.AddEntity(e)
+
+	w.Update(1.5)
+	if space.Position != (Point{10, 5}) {
+		t.Errorf("position should be {10, 5}, not %v", space.Position)
+	}
+
+	w.Update(1)
+	if space.Position != (Point{10, 10}) {
+		t.Errorf("position should be {10, 10}, not %v", space.Position)
+	}
+
+	if len(completed) != 1 || completed[0] != seq {
+		t.Errorf("a TweenCompleteMessage should have been sent for the sequence, got %v", completed)
+	}
+
+	if tc.Running() {
+		t.Error("TweenComponent should not be running after the sequence has finished")
+	}
+}
+
+func TestTweenParallel(t *testing.T) {
+	ts, w := newTestTweenSystem()
+
+	space := &SpaceComponent{}
+	par := Parallel(
+		NewTween(PositionTarget(space), time.Second, 10, 10),
+		NewTween(SizeTarget(space), 2*time.Second, 20, 20),
+	)
+	par.Delay = time.Second
+	ts.Add(par)
+
+	w.Update(1)
+	if space.Position != (Point{}) {
+		t.Errorf("position should not change during the delay, not %v", space.Position)
+	}
+
+	w.Update(1)
+	if space.Position != (Point{10, 10}) || space.Width != 10 {
+		t.Errorf("position should be {10, 10} and width 10, not %v and %v", space.Position, space.Width)
+	}
+}
+
+func TestTweenFewerValues(t *testing.T) {
+	ts, w := newTestTweenSystem()
+
+	space := &SpaceComponent{Position: Point{0, 4}}
+	e := ecs.NewEntity([]string{ts.Type()})
+	tc := &TweenComponent{}
+	tc.Add(NewTween(PositionTarget(space), time.Second, 10))
+	e.AddComponent(tc)
+	w.AddEntity(e)
+
+	w.Update(0.5)
+	if space.Position != (Point{5, 4}) {
+		t.Errorf("values without a target should be left as they are, position should be {5, 4}, not %v", space.Position)
+	}
+}